@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// Achievements unlock from game events and are recorded on the owning
+// player's profile.
+
+type achievement struct {
+	ID    string
+	Name  string
+	Desc  string
+	check func(w *World, e LogEvent, p *Profile) bool
+}
+
+type AchievementStatus struct {
+	ID         string     `json:"id"`
+	Name       string     `json:"name"`
+	Desc       string     `json:"desc"`
+	UnlockedAt *time.Time `json:"unlocked_at,omitempty"`
+}
+
+var achievements = []achievement{
+	{"first_victory", "First Victory", "Reach the end of a dungeon", func(w *World, e LogEvent, p *Profile) bool {
+		return e.Type == "run_finished"
+	}},
+	{"boss_slayer", "Boss Slayer", "Defeat a boss", func(w *World, e LogEvent, p *Profile) bool {
+		return e.Type == "boss_defeated"
+	}},
+	{"tireless", "Tireless", "Finish a run without resting", func(w *World, e LogEvent, p *Profile) bool {
+		return e.Type == "run_finished" && w.RunStats["rested"] == 0
+	}},
+	{"last_one_standing", "Last One Standing", "Finish a run with a single hero on their feet", func(w *World, e LogEvent, p *Profile) bool {
+		return e.Type == "run_finished" && standing(w) == 1
+	}},
+	{"trap_master", "Trap Master", "Disarm 5 traps", func(w *World, e LogEvent, p *Profile) bool {
+		return p.Counters["trap_disarmed"] >= 5
+	}},
+}
+
+// trackedEvents are the event types counted on profiles; everything else is
+// ignored so profiles aren't rewritten on every hit and miss.
+var trackedEvents = map[string]bool{
+	"enemy_defeated": true,
+	"boss_defeated":  true,
+	"trap_triggered": true,
+	"trap_disarmed":  true,
+	"rested":         true,
+	"run_finished":   true,
+	"run_lost":       true,
+}
+
+func init() {
+	eventListeners = append(eventListeners, onAchievementEvent)
+}
+
+// onAchievementEvent bumps the owning profile's lifetime counters and checks
+// every achievement not yet unlocked. Anonymous worlds don't earn any.
+func onAchievementEvent(w *World, e LogEvent) {
+	if !trackedEvents[e.Type] {
+		return
+	}
+	unlocked := []string{}
+	withProfile(w, func(p *Profile) {
+		p.Counters[e.Type]++
+		for _, a := range achievements {
+			if _, ok := p.Unlocked[a.ID]; ok || !a.check(w, e, p) {
+				continue
+			}
+			p.Unlocked[a.ID] = time.Now()
+			unlocked = append(unlocked, a.Name)
+		}
+	})
+	for _, name := range unlocked {
+		emit(w, LogEvent{Type: "achievement", Detail: name})
+	}
+}
+
+func achievementsFor(p *Profile) []AchievementStatus {
+	out := []AchievementStatus{}
+	for _, a := range achievements {
+		st := AchievementStatus{ID: a.ID, Name: a.Name, Desc: a.Desc}
+		if t, ok := p.Unlocked[a.ID]; ok {
+			st.UnlockedAt = &t
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+// achievementsHandler serves GET /achievements?profile=id.
+func achievementsHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("profile")
+	if id == "" {
+		apierr.Write(w, r, apierr.Invalid("profile", "profile required"))
+		return
+	}
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	p := profiles[id]
+	if p == nil {
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "profile not found"))
+		return
+	}
+	writeJSON(w, achievementsFor(p))
+}
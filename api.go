@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// Resource routes for worlds. They share their game logic with the legacy
+// verb routes (/generate, /party, /explore, /state, /talk, /equip); only the
+// way the world is addressed differs.
+
+// Action is the body of POST /worlds/{id}/actions and of the WebSocket "act"
+// message: a Move, or talk or equip with their own fields. Equip names its
+// hero in Move.Hero, as revive does.
+type Action struct {
+	Move
+	Roles  []string `json:"roles,omitempty"`  // party (WebSocket only)
+	NPC    string   `json:"npc,omitempty"`    // talk
+	Option string   `json:"option,omitempty"` // talk
+	Item   string   `json:"item,omitempty"`   // equip; empty to unequip Slot
+	Slot   string   `json:"slot,omitempty"`   // equip
+}
+
+func perform(wld *World, a Action) error {
+	switch a.Action {
+	case "party":
+		return assembleParty(wld, a.Roles)
+	case "talk":
+		return talkTo(wld, a.NPC, a.Option)
+	case "equip":
+		return equipHero(wld, a.Hero, a.Item, a.Slot)
+	default:
+		return play(wld, a.Move)
+	}
+}
+
+// lookupWorld finds the world named by the {id} path segment, answering 404
+// itself when there is none.
+func lookupWorld(w http.ResponseWriter, r *http.Request) (*World, bool) {
+	storeMu.Lock()
+	wld, ok := store[r.PathValue("id")]
+	storeMu.Unlock()
+	if !ok {
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "session not found"))
+	}
+	return wld, ok
+}
+
+// createWorldHandler serves POST /worlds with the same body as /generate.
+func createWorldHandler(w http.ResponseWriter, r *http.Request) {
+	var req worldOptions
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, r, apierr.BadBody(err))
+		return
+	}
+	wld := createWorld(req)
+	w.Header().Set("Location", "/worlds/"+wld.ID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	writeView(w, wld)
+}
+
+// getWorldHandler serves GET /worlds/{id}.
+func getWorldHandler(w http.ResponseWriter, r *http.Request) {
+	showWorld(w, r, r.PathValue("id"))
+}
+
+// deleteWorldHandler serves DELETE /worlds/{id}: the world is dropped from
+// memory and disk and anyone following it is disconnected.
+func deleteWorldHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	storeMu.Lock()
+	_, ok := store[id]
+	delete(store, id)
+	storeMu.Unlock()
+	if !ok {
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "session not found"))
+		return
+	}
+	closeFeed(id)
+	if err := os.Remove(dataPath("worlds", "world_"+id+".json")); err != nil && !os.IsNotExist(err) {
+		log.Printf("failed to remove world file: %v", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// worldPartyHandler serves POST /worlds/{id}/party {"roles": [...]}.
+func worldPartyHandler(w http.ResponseWriter, r *http.Request) {
+	wld, ok := lookupWorld(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Roles []string `json:"roles"`
+	}
+	// An empty body asks for the default party.
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && r.ContentLength != 0 {
+		apierr.Write(w, r, apierr.BadBody(err))
+		return
+	}
+	if err := assembleParty(wld, req.Roles); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeView(w, wld)
+}
+
+// worldActionHandler serves POST /worlds/{id}/actions with an Action.
+func worldActionHandler(w http.ResponseWriter, r *http.Request) {
+	wld, ok := lookupWorld(w, r)
+	if !ok {
+		return
+	}
+	var a Action
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		apierr.Write(w, r, apierr.BadBody(err))
+		return
+	}
+	if a.Action == "party" {
+		apierr.Write(w, r, apierr.Invalid("action", "use POST /worlds/{id}/party to assemble a party"))
+		return
+	}
+	if err := perform(wld, a); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeView(w, wld)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Hero classes come from catalog/classes.json. The copy under the assets
+// directory is read at startup so the roster can be tuned without a rebuild;
+// the embedded copy is the fallback when there is none.
+
+type HeroClass struct {
+	Role      string         `json:"role"`
+	Name      string         `json:"name"`
+	MaxHP     int            `json:"max_hp"`
+	Stats     map[string]int `json:"stats"`
+	Abilities []string       `json:"abilities"`
+	Themes    []string       `json:"themes,omitempty"` // empty means every theme
+}
+
+// Abilities the engine understands:
+//   taunt        enemies aim at this hero half the time
+//   power_strike +4 damage per hit
+//   arcane_bolt  +int/2 damage per hit
+//   crit         20% chance to deal double damage
+//   revive       lets the revive move pull a downed ally up without an item
+//   trap_sense   +30% chance to disarm traps
+
+//go:embed catalog/classes.json
+var defaultClasses []byte
+
+const classesFile = "catalog/classes.json"
+
+var (
+	classes       []HeroClass
+	defaultRoster = []string{"tank", "attacker", "healer", "support"}
+)
+
+func loadClasses() {
+	data, err := os.ReadFile(assetPath(classesFile))
+	if err != nil {
+		data = defaultClasses
+	}
+	if err := json.Unmarshal(data, &classes); err != nil {
+		log.Printf("failed to parse %s, using built-in classes: %v", classesFile, err)
+		if err := json.Unmarshal(defaultClasses, &classes); err != nil {
+			log.Fatalf("built-in class catalog is invalid: %v", err)
+		}
+	}
+}
+
+func classFor(role string) (HeroClass, bool) {
+	for _, c := range classes {
+		if c.Role == role {
+			return c, true
+		}
+	}
+	return HeroClass{}, false
+}
+
+func (c HeroClass) availableIn(theme string) bool {
+	if len(c.Themes) == 0 {
+		return true
+	}
+	for _, t := range c.Themes {
+		if t == theme {
+			return true
+		}
+	}
+	return false
+}
+
+func heroFromClass(c HeroClass, n int) Hero {
+	stats := map[string]int{}
+	for k, v := range c.Stats {
+		stats[k] = v
+	}
+	return Hero{
+		Name:      fmt.Sprintf("%s-%d", c.Name, n),
+		Role:      c.Role,
+		MaxHP:     c.MaxHP,
+		HP:        c.MaxHP,
+		Stats:     stats,
+		Abilities: append([]string{}, c.Abilities...),
+		Equipment: map[string]string{},
+	}
+}
+
+func hasAbility(h Hero, ability string) bool {
+	for _, a := range h.Abilities {
+		if a == ability {
+			return true
+		}
+	}
+	return false
+}
+
+// classesHandler serves GET /classes, optionally filtered by ?theme=.
+func classesHandler(w http.ResponseWriter, r *http.Request) {
+	theme := r.URL.Query().Get("theme")
+	out := []HeroClass{}
+	for _, c := range classes {
+		if theme == "" || c.availableIn(theme) {
+			out = append(out, c)
+		}
+	}
+	writeJSON(w, out)
+}
+
+func partyBase() int { return int(time.Now().UnixNano() % 1000) }
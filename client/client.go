@@ -0,0 +1,198 @@
+// Package client talks to a Void Spark server over its HTTP API:
+//
+//	c := client.New("http://localhost:8080")
+//	w, err := c.Generate(ctx, client.WorldOptions{Prompt: "a flooded crypt"})
+//	w, err = c.CreateParty(ctx, w.ID)
+//	w, err = c.Act(ctx, w.ID, client.Action{Action: "explore"})
+//
+// Failed requests return an *Error carrying the server's error code. Reads
+// (State, ListWorlds and opening a StreamEvents stream) are retried on
+// network errors and 5xx answers; anything that changes a world is sent once.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	BaseURL string
+	// HTTPClient sends the requests. Leave its Timeout at zero if you use
+	// StreamEvents, or the stream will be cut off; use contexts instead.
+	HTTPClient *http.Client
+	// Retries is how many more times an idempotent request is tried.
+	Retries int
+	// Backoff is the wait before the first retry; it doubles for each one
+	// after.
+	Backoff time.Duration
+}
+
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		HTTPClient: http.DefaultClient,
+		Retries:    2,
+		Backoff:    200 * time.Millisecond,
+	}
+}
+
+// Generate creates a world.
+func (c *Client) Generate(ctx context.Context, opts WorldOptions) (*World, error) {
+	var w World
+	if err := c.do(ctx, http.MethodPost, "/worlds", nil, opts, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// CreateParty assembles the world's party from the given roles, or the
+// default party for its theme when there are none. It is a no-op once the
+// world has a party.
+func (c *Client) CreateParty(ctx context.Context, id string, roles ...string) (*World, error) {
+	var w World
+	body := struct {
+		Roles []string `json:"roles,omitempty"`
+	}{roles}
+	if err := c.do(ctx, http.MethodPost, "/worlds/"+url.PathEscape(id)+"/party", nil, body, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// Act plays one move and returns the world after it.
+func (c *Client) Act(ctx context.Context, id string, a Action) (*World, error) {
+	var w World
+	if err := c.do(ctx, http.MethodPost, "/worlds/"+url.PathEscape(id)+"/actions", nil, a, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// State fetches the player view of a world.
+func (c *Client) State(ctx context.Context, id string) (*World, error) {
+	var w World
+	if err := c.do(ctx, http.MethodGet, "/worlds/"+url.PathEscape(id), nil, nil, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+// ListWorlds fetches one page of world summaries.
+func (c *Client) ListWorlds(ctx context.Context, opts ListOptions) (*WorldPage, error) {
+	q := url.Values{}
+	for k, v := range map[string]string{
+		"theme": opts.Theme, "aesthetic": opts.Aesthetic, "dimension": opts.Dimension,
+		"game_state": opts.GameState, "q": opts.Query, "sort": opts.Sort,
+		"order": opts.Order, "cursor": opts.Cursor,
+	} {
+		if v != "" {
+			q.Set(k, v)
+		}
+	}
+	if !opts.CreatedAfter.IsZero() {
+		q.Set("created_after", opts.CreatedAfter.Format(time.RFC3339))
+	}
+	if !opts.CreatedBefore.IsZero() {
+		q.Set("created_before", opts.CreatedBefore.Format(time.RFC3339))
+	}
+	if opts.Limit > 0 {
+		q.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	var page WorldPage
+	if err := c.do(ctx, http.MethodGet, "/worlds", q, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// do sends a JSON request and decodes the response into out, retrying GETs.
+func (c *Client) do(ctx context.Context, method, path string, q url.Values, body, out any) error {
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+	resp, err := c.send(ctx, method, path, q, data, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// send returns a successful response, whose body the caller must close. GETs
+// are retried on network errors and 5xx answers.
+func (c *Client) send(ctx context.Context, method, path string, q url.Values, body []byte, header http.Header) (*http.Response, error) {
+	u := c.BaseURL + path
+	if len(q) > 0 {
+		u += "?" + q.Encode()
+	}
+	attempts := 1
+	if method == http.MethodGet {
+		attempts += c.Retries
+	}
+	wait := c.Backoff
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			wait *= 2
+		}
+		var resp *http.Response
+		var retry bool
+		resp, retry, err = c.try(ctx, method, u, body, header)
+		if err == nil {
+			return resp, nil
+		}
+		if !retry || ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+func (c *Client) try(ctx context.Context, method, u string, body []byte, header http.Header) (resp *http.Response, retry bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if req.Header.Get("Accept") == "" {
+		req.Header.Set("Accept", "application/json")
+	}
+	hc := c.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err = hc.Do(req)
+	if err != nil {
+		return nil, true, err
+	}
+	if resp.StatusCode < 400 {
+		return resp, false, nil
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	resp.Body.Close()
+	return nil, resp.StatusCode >= 500, decodeError(resp, data)
+}
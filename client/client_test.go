@@ -0,0 +1,142 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func envelope(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	fmt.Fprintf(w, `{"error": {"code": %q, "message": "nope", "request_id": "r1"}}`, code)
+}
+
+func TestRetries(t *testing.T) {
+	var hits, status atomic.Int32
+	status.Store(http.StatusServiceUnavailable)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		if status.Load() == http.StatusNotFound {
+			envelope(w, http.StatusNotFound, NotFound)
+			return
+		}
+		envelope(w, http.StatusServiceUnavailable, Internal)
+	}))
+	defer srv.Close()
+	c := New(srv.URL)
+	c.Backoff = time.Millisecond
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func() error
+		hits int32
+	}{
+		{"GET", func() error { _, err := c.State(ctx, "w1"); return err }, int32(1 + c.Retries)},
+		{"POST", func() error { _, err := c.Act(ctx, "w1", Action{Action: "explore"}); return err }, 1},
+	}
+	for _, tt := range tests {
+		hits.Store(0)
+		if err := tt.call(); !IsCode(err, Internal) {
+			t.Errorf("%s: err = %v, want %s", tt.name, err, Internal)
+		}
+		if got := hits.Load(); got != tt.hits {
+			t.Errorf("%s: sent %d times, want %d", tt.name, got, tt.hits)
+		}
+	}
+
+	// A 4xx is the server's answer, not a failure to retry.
+	status.Store(http.StatusNotFound)
+	hits.Store(0)
+	if _, err := c.State(ctx, "w1"); !IsCode(err, NotFound) || hits.Load() != 1 {
+		t.Errorf("404: err = %v after %d tries, want not_found after 1", err, hits.Load())
+	}
+}
+
+func TestIsCode(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/worlds/proxy" {
+			http.Error(w, "bad gateway", http.StatusBadGateway)
+			return
+		}
+		envelope(w, http.StatusConflict, EventPending)
+	}))
+	defer srv.Close()
+	c := New(srv.URL)
+	c.Retries = 0
+	ctx := context.Background()
+
+	_, err := c.Act(ctx, "w1", Action{Action: "explore"})
+	if !IsCode(err, EventPending) {
+		t.Errorf("IsCode(%v, %s) = false", err, EventPending)
+	}
+	if IsCode(err, NotFound) {
+		t.Errorf("IsCode(%v, %s) = true", err, NotFound)
+	}
+	if !IsCode(fmt.Errorf("explore: %w", err), EventPending) {
+		t.Error("IsCode doesn't see through wrapping")
+	}
+	if e := err.(*Error); e.StatusCode != http.StatusConflict || e.RequestID != "r1" {
+		t.Errorf("error = %+v, want status 409 and request r1", e)
+	}
+
+	_, err = c.State(ctx, "proxy")
+	if e, ok := err.(*Error); !ok || e.Code != "" || e.StatusCode != http.StatusBadGateway {
+		t.Errorf("non-envelope answer: err = %#v", err)
+	}
+	if IsCode(err, Internal) || IsCode(nil, Internal) || IsCode(context.Canceled, Internal) {
+		t.Error("IsCode matched an error without that code")
+	}
+}
+
+func TestStreamResume(t *testing.T) {
+	var conns atomic.Int32
+	lastIDs := make(chan string, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastIDs <- r.Header.Get("Last-Event-ID")
+		w.Header().Set("Content-Type", "text/event-stream")
+		from := 1
+		if conns.Add(1) > 1 {
+			from = 3
+		}
+		// Two events a connection, then drop it.
+		for seq := from; seq < from+2; seq++ {
+			fmt.Fprintf(w, "id: %d\nevent: event\ndata: {\"seq\": %d, \"type\": \"room_entered\"}\n\n", seq, seq)
+		}
+		fmt.Fprint(w, ": keep-alive\n\nevent: state\ndata: {\"current\": 1}\n\n")
+	}))
+	defer srv.Close()
+	c := New(srv.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	s, err := c.StreamEvents(ctx, "w1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	var seqs []int
+	for len(seqs) < 3 {
+		m, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Event != nil {
+			seqs = append(seqs, m.Event.Seq)
+		}
+	}
+	if fmt.Sprint(seqs) != "[1 2 3]" || s.LastSeq() != 3 {
+		t.Errorf("got events %v, last seq %d", seqs, s.LastSeq())
+	}
+	if first, second := <-lastIDs, <-lastIDs; first != "" || second != "2" {
+		t.Errorf("Last-Event-ID: first %q, after reconnecting %q; want none, then 2", first, second)
+	}
+	if w, err := s.World(); err != nil || w.Current != 1 {
+		t.Errorf("World() = %+v, %v", w, err)
+	}
+}
@@ -0,0 +1,78 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// Error codes the server answers with; see Error.Code.
+const (
+	BadJSON            = apierr.BadJSON
+	InvalidField       = apierr.InvalidField
+	InvalidAction      = apierr.InvalidAction
+	NotFound           = apierr.NotFound
+	MethodNotAllowed   = apierr.MethodNotAllowed
+	Forbidden          = apierr.Forbidden
+	EventPending       = apierr.EventPending
+	WrongRoom          = apierr.WrongRoom
+	AlreadyExists      = apierr.AlreadyExists
+	AlreadyPlayed      = apierr.AlreadyPlayed
+	AlreadyUnlocked    = apierr.AlreadyUnlocked
+	NotSubscribed      = apierr.NotSubscribed
+	RunOver            = apierr.RunOver
+	WorldDeleted       = apierr.WorldDeleted
+	InsufficientShards = apierr.InsufficientShards
+	UpgradeRequired    = apierr.UpgradeRequired
+	Internal           = apierr.Internal
+)
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is a request the server refused, decoded from its error envelope.
+// Code is empty when the response wasn't an envelope, e.g. from a proxy.
+type Error struct {
+	StatusCode int          `json:"-"`
+	Code       string       `json:"code"`
+	Message    string       `json:"message"`
+	Fields     []FieldError `json:"fields,omitempty"`
+	RequestID  string       `json:"request_id,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("voidspark: %d %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("voidspark: %s: %s", e.Code, e.Message)
+}
+
+// IsCode reports whether err is an *Error with the given code.
+func IsCode(err error, code string) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Code == code
+}
+
+func decodeError(resp *http.Response, body []byte) *Error {
+	var env struct {
+		Error *Error `json:"error"`
+	}
+	if json.Unmarshal(body, &env) == nil && env.Error != nil && env.Error.Code != "" {
+		env.Error.StatusCode = resp.StatusCode
+		return env.Error
+	}
+	msg := strings.TrimSpace(string(body))
+	if len(msg) > 200 {
+		msg = msg[:200]
+	}
+	if msg == "" {
+		msg = http.StatusText(resp.StatusCode)
+	}
+	return &Error{StatusCode: resp.StatusCode, Message: msg, RequestID: resp.Header.Get("X-Request-ID")}
+}
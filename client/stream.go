@@ -0,0 +1,145 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// StreamMessage is one message of a world's event stream: either an event or
+// the player-view fields that changed since the last state message.
+type StreamMessage struct {
+	Event *LogEvent
+	State map[string]json.RawMessage
+}
+
+// EventStream follows a world over Server-Sent Events. If the connection
+// drops it reconnects from the last event seen; once the world is deleted
+// Next returns an *Error with code not_found.
+type EventStream struct {
+	c       *Client
+	ctx     context.Context
+	id      string
+	body    io.ReadCloser
+	r       *bufio.Reader
+	lastSeq int
+	state   map[string]json.RawMessage
+}
+
+// StreamEvents opens the event stream of a world, starting after event
+// lastSeq (0 for all of them). Cancelling ctx ends the stream.
+func (c *Client) StreamEvents(ctx context.Context, id string, lastSeq int) (*EventStream, error) {
+	s := &EventStream{c: c, ctx: ctx, id: id, lastSeq: lastSeq, state: map[string]json.RawMessage{}}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *EventStream) connect() error {
+	h := http.Header{"Accept": {"text/event-stream"}}
+	if s.lastSeq > 0 {
+		h.Set("Last-Event-ID", strconv.Itoa(s.lastSeq))
+	}
+	resp, err := s.c.send(s.ctx, http.MethodGet, "/worlds/"+url.PathEscape(s.id)+"/events", nil, nil, h)
+	if err != nil {
+		return err
+	}
+	s.body, s.r = resp.Body, bufio.NewReader(resp.Body)
+	return nil
+}
+
+// Next blocks until the next message arrives.
+func (s *EventStream) Next() (StreamMessage, error) {
+	for {
+		kind, data, err := s.read()
+		if err != nil {
+			if s.ctx.Err() != nil {
+				return StreamMessage{}, s.ctx.Err()
+			}
+			s.body.Close()
+			if err := s.connect(); err != nil {
+				return StreamMessage{}, err
+			}
+			continue
+		}
+		switch kind {
+		case "event":
+			var e LogEvent
+			if err := json.Unmarshal(data, &e); err != nil {
+				return StreamMessage{}, err
+			}
+			s.lastSeq = e.Seq
+			return StreamMessage{Event: &e}, nil
+		case "state":
+			var diff map[string]json.RawMessage
+			if err := json.Unmarshal(data, &diff); err != nil {
+				return StreamMessage{}, err
+			}
+			for k, v := range diff {
+				if string(v) == "null" {
+					delete(s.state, k)
+				} else {
+					s.state[k] = v
+				}
+			}
+			return StreamMessage{State: diff}, nil
+		}
+	}
+}
+
+// read returns the type and data of the next SSE message, skipping comments.
+func (s *EventStream) read() (kind string, data []byte, err error) {
+	for {
+		line, err := s.r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case line == "":
+			if kind != "" {
+				return kind, data, nil
+			}
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event:"):
+			kind = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			if data != nil {
+				data = append(data, '\n')
+			}
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " ")...)
+		}
+	}
+}
+
+// LastSeq is the Seq of the last event returned by Next.
+func (s *EventStream) LastSeq() int { return s.lastSeq }
+
+// World is the player view built from every state message so far, without
+// its events and log.
+func (s *EventStream) World() (*World, error) {
+	if len(s.state) == 0 {
+		return nil, errors.New("voidspark: no state received yet")
+	}
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		return nil, err
+	}
+	var w World
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, err
+	}
+	return &w, nil
+}
+
+func (s *EventStream) Close() error { return s.body.Close() }
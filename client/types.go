@@ -0,0 +1,170 @@
+package client
+
+import "time"
+
+// The types below mirror the JSON of the server's player view; see
+// /openapi.json for the authoritative shapes. Fields the player view hides
+// (undiscovered rooms, the event deck) simply come back empty.
+
+type World struct {
+	ID             string         `json:"id"`
+	Prompt         string         `json:"prompt"`
+	Dimension      string         `json:"dimension"`
+	Theme          string         `json:"theme"`
+	Aesthetic      string         `json:"aesthetic"`
+	Difficulty     string         `json:"difficulty"`
+	Rooms          []Room         `json:"rooms"`
+	Seed           int64          `json:"seed,omitempty"` // only in the debug view
+	Current        int            `json:"current"`
+	GameState      string         `json:"game_state"`
+	Permadeath     bool           `json:"permadeath"`
+	Party          []Hero         `json:"party"`
+	Inventory      []string       `json:"inventory"`
+	Morale         int            `json:"morale"`
+	Fatigue        int            `json:"fatigue"`
+	RoomsSinceRest int            `json:"rooms_since_rest"`
+	Event          *Event         `json:"event,omitempty"` // encounter waiting for a choice
+	Objectives     []Objective    `json:"objectives"`
+	Kills          int            `json:"kills"`
+	Moves          int            `json:"moves"`
+	NPCs           []NPC          `json:"npcs"`
+	Player         string         `json:"player"`
+	Profile        string         `json:"profile,omitempty"`
+	Meta           bool           `json:"meta"`
+	LootFound      int            `json:"loot_found"`
+	Score          int            `json:"score"`
+	Daily          string         `json:"daily,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	RunStats       map[string]int `json:"run_stats"`
+	Events         []LogEvent     `json:"events"`
+	Log            []string       `json:"log"`
+}
+
+type Room struct {
+	Index      int    `json:"index"`
+	Type       string `json:"type"` // combat/loot/trap/rest, or unknown until discovered
+	Desc       string `json:"desc"`
+	State      string `json:"state,omitempty"`
+	Boss       bool   `json:"boss,omitempty"`
+	TrapKnown  bool   `json:"trap_known,omitempty"`
+	Discovered bool   `json:"discovered"`
+	Visited    bool   `json:"visited"`
+}
+
+type Hero struct {
+	Name      string            `json:"name"`
+	Role      string            `json:"role"`
+	HP        int               `json:"hp"`
+	MaxHP     int               `json:"max_hp"`
+	Stats     map[string]int    `json:"stats"`
+	Abilities []string          `json:"abilities"`
+	Equipment map[string]string `json:"equipment"`
+	Status    string            `json:"status,omitempty"` // "", downed or dead
+	BleedOut  int               `json:"bleed_out,omitempty"`
+}
+
+type Event struct {
+	Kind    string        `json:"kind"`
+	Text    string        `json:"text"`
+	Choices []EventChoice `json:"choices"`
+}
+
+type EventChoice struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+type Objective struct {
+	Kind     string `json:"kind"`
+	Desc     string `json:"desc"`
+	Target   int    `json:"target"`
+	Progress int    `json:"progress"`
+	Room     int    `json:"room,omitempty"`
+	Status   string `json:"status"`
+	Reward   string `json:"reward,omitempty"`
+}
+
+type NPC struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Personality string           `json:"personality"`
+	Room        int              `json:"room"`
+	Node        string           `json:"node"`
+	Line        string           `json:"line"`
+	Options     []DialogueOption `json:"options"`
+}
+
+type DialogueOption struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+// LogEvent is one thing that happened in a world, numbered by Seq.
+type LogEvent struct {
+	Seq    int       `json:"seq"`
+	Type   string    `json:"type"`
+	Actor  string    `json:"actor,omitempty"`
+	Target string    `json:"target,omitempty"`
+	Amount int       `json:"amount,omitempty"`
+	Value  int       `json:"value,omitempty"`
+	Room   int       `json:"room"`
+	Detail string    `json:"detail,omitempty"`
+	Time   time.Time `json:"time"`
+	Text   string    `json:"text"`
+}
+
+// WorldOptions is the body of Generate.
+type WorldOptions struct {
+	Prompt     string `json:"prompt"`
+	Difficulty string `json:"difficulty,omitempty"`
+	Permadeath bool   `json:"permadeath,omitempty"`
+	Player     string `json:"player,omitempty"`
+	Seed       int64  `json:"seed,omitempty"` // 0 picks a fresh seed
+	Meta       bool   `json:"meta,omitempty"`
+}
+
+// Action is one move for Act: explore (the default), flee with a Direction,
+// choose with a Choice, revive a downed Hero, talk with an NPC and Option, or
+// equip with a Hero and an Item (or a Slot to unequip).
+type Action struct {
+	Action    string `json:"action,omitempty"`
+	Direction string `json:"direction,omitempty"`
+	Choice    string `json:"choice,omitempty"`
+	NPC       string `json:"npc,omitempty"`
+	Option    string `json:"option,omitempty"`
+	Hero      string `json:"hero,omitempty"`
+	Item      string `json:"item,omitempty"`
+	Slot      string `json:"slot,omitempty"`
+}
+
+type WorldSummary struct {
+	ID         string    `json:"id"`
+	Prompt     string    `json:"prompt"`
+	Theme      string    `json:"theme"`
+	Aesthetic  string    `json:"aesthetic"`
+	Dimension  string    `json:"dimension"`
+	Difficulty string    `json:"difficulty"`
+	GameState  string    `json:"game_state"`
+	Player     string    `json:"player"`
+	Score      int       `json:"score"`
+	CreatedAt  time.Time `json:"created_at"`
+	Live       bool      `json:"live"`
+}
+
+type WorldPage struct {
+	Worlds     []WorldSummary `json:"worlds"`
+	Total      int            `json:"total"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// ListOptions filters, orders and pages ListWorlds. Zero values are left to
+// the server's defaults.
+type ListOptions struct {
+	Theme, Aesthetic, Dimension, GameState string
+	Query                                  string // substring of the prompt
+	CreatedAfter, CreatedBefore            time.Time
+	Sort                                   string // created, prompt, score or theme
+	Order                                  string // asc or desc
+	Limit                                  int
+	Cursor                                 string // NextCursor of the previous page
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/NarlaRohan050/Voidspark/client"
+)
+
+// TestClientWorld decodes the player view of worlds played to the end into
+// client.World, so a field the server adds without the client fails here.
+func TestClientWorld(t *testing.T) {
+	saved := cfg
+	cfg.DataDir = t.TempDir()
+	defer func() { cfg = saved }()
+	if err := os.MkdirAll(dataPath("worlds"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	loadClasses()
+
+	for seed := int64(1); seed <= 20; seed++ {
+		wld := createWorld(worldOptions{Prompt: "a haunted crypt", Seed: seed, Player: "tester", Meta: true})
+		if err := assembleParty(wld, nil); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 100 && wld.GameState == "exploring"; i++ {
+			m := Move{Action: "explore"}
+			if wld.Event != nil {
+				m = Move{Action: "choose", Choice: wld.Event.Choices[0].ID}
+			}
+			// Decode mid-run too, while an event is waiting.
+			decodeView(t, wld)
+			_ = play(wld, m)
+		}
+		decodeView(t, wld)
+		storeMu.Lock()
+		delete(store, wld.ID)
+		storeMu.Unlock()
+	}
+}
+
+func decodeView(t *testing.T, wld *World) {
+	t.Helper()
+	data, err := json.Marshal(playerView(wld))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var w client.World
+	if err := dec.Decode(&w); err != nil {
+		t.Fatalf("world %s (seed %d): %v", wld.ID, wld.Seed, err)
+	}
+	if w.ID != wld.ID || len(w.Rooms) != len(wld.Rooms) || len(w.Events) != len(wld.Events) {
+		t.Fatalf("world %s: decoded %d rooms and %d events, want %d and %d",
+			wld.ID, len(w.Rooms), len(w.Events), len(wld.Rooms), len(wld.Events))
+	}
+}
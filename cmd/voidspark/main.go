@@ -9,6 +9,9 @@ import (
 	"strconv"
 	"sync"
 	"time"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+	"github.com/NarlaRohan050/Voidspark/internal/config"
 )
 
 type World struct {
@@ -23,15 +26,30 @@ type World struct {
 
 var (
 	store = make(map[string]*World)
+	dirty = make(map[string]bool) // changed since last saved
 	mu    sync.RWMutex
 )
 
+// cfg is this server's configuration; see internal/config. The only feature
+// is preview, the file server on /data/.
+var cfg = defaultConfig()
+
+func defaultConfig() config.Config {
+	c := config.Defaults()
+	c.DataDir = filepath.Join("..", "data") // next to the binary built in cmd/voidspark/
+	c.Features = map[string]bool{"preview": true}
+	return c
+}
+
 func main() {
-	// ✅ Moved inside main()
-	dataDir := filepath.Join("..", "data") // relative to cmd/voidspark/
+	var err error
+	if cfg, err = config.Load("voidspark", defaultConfig(), os.Args[1:]); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	log.Printf("config: %s", cfg)
 
-	os.MkdirAll(filepath.Join(dataDir, "worlds"), 0755)
-	os.MkdirAll(filepath.Join(dataDir, "web", "preview"), 0755)
+	os.MkdirAll(filepath.Join(cfg.DataDir, "worlds"), 0755)
+	os.MkdirAll(filepath.Join(cfg.DataDir, "web", "preview"), 0755)
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/generate", generateHandler)
@@ -40,28 +58,22 @@ func main() {
 	mux.HandleFunc("/explore", exploreHandler)
 	mux.HandleFunc("/state", stateHandler)
 	mux.HandleFunc("/api/latest-world", latestWorldHandler)
-	mux.Handle("/data/", http.StripPrefix("/data/", http.FileServer(http.Dir(dataDir)))) // ✅ Use dataDir
-
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-		mux.ServeHTTP(w, r)
-	})
+	if cfg.Enabled("preview") {
+		mux.Handle("/data/", http.StripPrefix("/data/", http.FileServer(http.Dir(cfg.DataDir))))
+	}
 
 	log.Println("✅ Void Spark — pure user-defined world engine")
-	log.Println("🌀 Preview: http://localhost:8080/data/web/preview/world_preview.html")
-	log.Fatal(http.ListenAndServe(":8080", handler))
+	log.Printf("🌀 Listening on %s, preview at /data/web/preview/world_preview.html", cfg.Addr)
+	if err := cfg.Serve(cfg.Server(apierr.WithRequestID(cfg.CORS(mux)))); err != nil {
+		log.Fatal(err)
+	}
+	flushWorlds()
 }
 
 func generateHandler(w http.ResponseWriter, r *http.Request) {
 	var req struct{ Prompt string `json:"prompt"` }
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid JSON"}`, http.StatusBadRequest)
+		apierr.Write(w, r, apierr.BadBody(err))
 		return
 	}
 
@@ -90,7 +102,7 @@ func refineHandler(w http.ResponseWriter, r *http.Request) {
 		Prompt string `json:"prompt"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid JSON"}`, http.StatusBadRequest)
+		apierr.Write(w, r, apierr.BadBody(err))
 		return
 	}
 
@@ -98,7 +110,7 @@ func refineHandler(w http.ResponseWriter, r *http.Request) {
 	wld, ok := store[req.ID]
 	mu.Unlock()
 	if !ok {
-		http.Error(w, `{"error":"world not found"}`, http.StatusNotFound)
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "world not found"))
 		return
 	}
 
@@ -117,7 +129,7 @@ func refineHandler(w http.ResponseWriter, r *http.Request) {
 func partyHandler(w http.ResponseWriter, r *http.Request) {
 	var req struct{ ID string `json:"id"` }
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid JSON"}`, http.StatusBadRequest)
+		apierr.Write(w, r, apierr.BadBody(err))
 		return
 	}
 
@@ -125,7 +137,7 @@ func partyHandler(w http.ResponseWriter, r *http.Request) {
 	wld, ok := store[req.ID]
 	mu.Unlock()
 	if !ok {
-		http.Error(w, `{"error":"world not found"}`, http.StatusNotFound)
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "world not found"))
 		return
 	}
 
@@ -139,6 +151,7 @@ func partyHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		wld.Log = append(wld.Log, "Agents added from prompt context")
 		wld.UpdatedAt = time.Now()
+		markDirty(wld.ID)
 	}
 
 	writeJSON(w, wld)
@@ -147,7 +160,7 @@ func partyHandler(w http.ResponseWriter, r *http.Request) {
 func exploreHandler(w http.ResponseWriter, r *http.Request) {
 	var req struct{ ID string `json:"id"` }
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, `{"error":"invalid JSON"}`, http.StatusBadRequest)
+		apierr.Write(w, r, apierr.BadBody(err))
 		return
 	}
 
@@ -155,12 +168,13 @@ func exploreHandler(w http.ResponseWriter, r *http.Request) {
 	wld, ok := store[req.ID]
 	mu.Unlock()
 	if !ok {
-		http.Error(w, `{"error":"world not found"}`, http.StatusNotFound)
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "world not found"))
 		return
 	}
 
 	wld.Log = append(wld.Log, "Explored world per user action")
 	wld.UpdatedAt = time.Now()
+	markDirty(wld.ID)
 
 	writeJSON(w, wld)
 }
@@ -168,7 +182,7 @@ func exploreHandler(w http.ResponseWriter, r *http.Request) {
 func stateHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("id")
 	if id == "" {
-		http.Error(w, `{"error":"id required"}`, http.StatusBadRequest)
+		apierr.Write(w, r, apierr.Invalid("id", "id required"))
 		return
 	}
 
@@ -176,25 +190,35 @@ func stateHandler(w http.ResponseWriter, r *http.Request) {
 	wld := store[id]
 	mu.RUnlock()
 	if wld == nil {
-		http.Error(w, `{"error":"world not found"}`, http.StatusNotFound)
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "world not found"))
 		return
 	}
 	writeJSON(w, wld)
 }
 
 func latestWorldHandler(w http.ResponseWriter, r *http.Request) {
-	files, _ := filepath.Glob("../data/worlds/*.json")
+	files, _ := filepath.Glob(filepath.Join(cfg.DataDir, "worlds", "*.json"))
 	if len(files) == 0 {
-		http.Error(w, `{"error":"no worlds"}`, http.StatusNotFound)
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "no worlds"))
 		return
 	}
 
+	// Pick by modification time; IDs don't sort reliably as strings.
 	var latest string
+	var latestTime time.Time
 	for _, f := range files {
-		if f > latest {
-			latest = f
+		info, err := os.Stat(f)
+		if err != nil {
+			continue
+		}
+		if info.ModTime().After(latestTime) {
+			latest, latestTime = f, info.ModTime()
 		}
 	}
+	if latest == "" {
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "no worlds"))
+		return
+	}
 
 	name := filepath.Base(latest)
 	writeJSON(w, map[string]string{"latest": name})
@@ -206,10 +230,37 @@ func saveWorld(wld *World) {
 		log.Printf("⚠️ JSON marshal failed for world %s: %v", wld.ID, err)
 		return
 	}
-	path := filepath.Join("../data", "worlds", "world_"+wld.ID+".json")
+	path := filepath.Join(cfg.DataDir, "worlds", "world_"+wld.ID+".json")
 	if err := os.WriteFile(path, data, 0644); err != nil {
 		log.Printf("⚠️ Save failed for world %s: %v", wld.ID, err)
+		return
+	}
+	mu.Lock()
+	delete(dirty, wld.ID)
+	mu.Unlock()
+}
+
+func markDirty(id string) {
+	mu.Lock()
+	dirty[id] = true
+	mu.Unlock()
+}
+
+// flushWorlds saves the worlds changed since they were last written, once the
+// server has stopped taking requests.
+func flushWorlds() {
+	mu.RLock()
+	worlds := []*World{}
+	for id := range dirty {
+		if wld := store[id]; wld != nil {
+			worlds = append(worlds, wld)
+		}
+	}
+	mu.RUnlock()
+	for _, wld := range worlds {
+		saveWorld(wld)
 	}
+	log.Printf("💾 Flushed %d world(s)", len(worlds))
 }
 
 func writeJSON(w http.ResponseWriter, v interface{}) {
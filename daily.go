@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// The daily challenge gives everyone the same world for a calendar day (UTC).
+// Seed and prompt are derived from the date, so any server produces the same
+// challenge with no outside service. Each player name gets one attempt per
+// day, recorded when the run starts so a bad start can't be rerolled.
+
+type dailyChallenge struct {
+	Date       string `json:"date"`
+	Seed       int64  `json:"seed"`
+	Prompt     string `json:"prompt"`
+	Difficulty string `json:"difficulty"`
+}
+
+var dailyPrompts = []string{
+	"a dark stone dungeon with countless treasure, candlelight, and traps",
+	"a derelict space station drifting near a dying star",
+	"a neon cyber city where you must escape the syndicate",
+	"an overgrown mossy crypt where monsters hunt the living",
+	"rescue a captive from a glowing crystal cavern",
+	"a midnight street race through a rain-soaked city track",
+}
+
+const dailyAttemptsFile = "scores/daily_attempts.json"
+
+var (
+	dailyAttempts   = map[string]map[string]bool{} // date -> player -> attempted
+	dailyAttemptsMu sync.Mutex
+)
+
+func today() string { return time.Now().UTC().Format("2006-01-02") }
+
+func dailyFor(date string) dailyChallenge {
+	h := fnv.New64a()
+	h.Write([]byte("voidspark-daily-" + date))
+	seed := int64(h.Sum64() >> 1)
+	d := dailyChallenge{Date: date, Seed: seed, Prompt: dailyPrompts[seed%int64(len(dailyPrompts))], Difficulty: "normal"}
+	if seed%7 == 0 {
+		d.Difficulty = "hard"
+	}
+	return d
+}
+
+func loadDailyAttempts() {
+	data, err := os.ReadFile(dataPath(dailyAttemptsFile))
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.Printf("failed to read daily attempts: %v", err)
+		return
+	}
+	dailyAttemptsMu.Lock()
+	defer dailyAttemptsMu.Unlock()
+	if err := json.Unmarshal(data, &dailyAttempts); err != nil {
+		log.Printf("failed to parse daily attempts: %v", err)
+	}
+}
+
+// claimDailyAttempt records player's attempt for date, returning false if
+// they already had one.
+func claimDailyAttempt(date, player string) bool {
+	key := strings.ToLower(strings.TrimSpace(player))
+	dailyAttemptsMu.Lock()
+	defer dailyAttemptsMu.Unlock()
+	if dailyAttempts[date][key] {
+		return false
+	}
+	if dailyAttempts[date] == nil {
+		dailyAttempts[date] = map[string]bool{}
+	}
+	dailyAttempts[date][key] = true
+	data, _ := json.MarshalIndent(dailyAttempts, "", "  ")
+	if err := os.MkdirAll(filepath.Dir(dataPath(dailyAttemptsFile)), 0755); err != nil {
+		log.Printf("failed to create scores folder: %v", err)
+		return true
+	}
+	if err := os.WriteFile(dataPath(dailyAttemptsFile), data, 0644); err != nil {
+		log.Printf("failed to save daily attempts: %v", err)
+	}
+	return true
+}
+
+// dailyHandler serves today's challenge on GET and starts a player's one
+// attempt on POST {"player": "..."}.
+func dailyHandler(w http.ResponseWriter, r *http.Request) {
+	d := dailyFor(today())
+	switch r.Method {
+	case "GET":
+		writeJSON(w, d)
+	case "POST":
+		var req struct {
+			Player string `json:"player"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierr.Write(w, r, apierr.BadBody(err))
+			return
+		}
+		if strings.TrimSpace(req.Player) == "" {
+			apierr.Write(w, r, apierr.Invalid("player", "player required"))
+			return
+		}
+		if !claimDailyAttempt(d.Date, req.Player) {
+			apierr.Write(w, r, apierr.New(apierr.AlreadyPlayed, "%s has already played today's challenge", req.Player))
+			return
+		}
+		wld := createWorld(worldOptions{Prompt: d.Prompt, Difficulty: d.Difficulty, Player: req.Player, Seed: d.Seed, Daily: d.Date})
+		writeView(w, wld)
+	default:
+		apierr.Write(w, r, apierr.New(apierr.MethodNotAllowed, "GET or POST only"))
+	}
+}
+
+// dailyLeaderboardHandler serves GET /daily/leaderboard?date=YYYY-MM-DD,
+// defaulting to today.
+func dailyLeaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = today()
+	}
+	if _, err := time.Parse("2006-01-02", date); err != nil {
+		apierr.Write(w, r, apierr.Invalid("date", "date must be YYYY-MM-DD"))
+		return
+	}
+	writeJSON(w, topScores(func(e ScoreEntry) bool { return e.Daily == date }, 100))
+}
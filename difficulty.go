@@ -0,0 +1,102 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+)
+
+// Difficulty tiers picked at generation time. The tier multiplier is combined
+// with room depth so danger grows the further the party gets.
+var difficultyTiers = map[string]float64{
+	"easy":   0.75,
+	"normal": 1.0,
+	"hard":   1.35,
+}
+
+func normalizeDifficulty(d string) string {
+	d = strings.ToLower(strings.TrimSpace(d))
+	if _, ok := difficultyTiers[d]; ok {
+		return d
+	}
+	return "normal"
+}
+
+// dangerScale returns the multiplier for enemy HP, enemy hits and trap damage
+// in room roomIndex (1-based). The first room is baseline and the last room is
+// 1.6x, before the tier multiplier is applied.
+func dangerScale(w *World, roomIndex int) float64 {
+	tier := difficultyTiers[normalizeDifficulty(w.Difficulty)]
+	depth := 0.0
+	if n := len(w.Rooms); n > 1 {
+		depth = float64(roomIndex-1) / float64(n-1)
+	}
+	if depth < 0 {
+		depth = 0
+	}
+	if depth > 1 {
+		depth = 1
+	}
+	return tier * (1 + 0.6*depth)
+}
+
+func scaled(base int, scale float64) int {
+	v := int(math.Round(float64(base) * scale))
+	if v < 1 {
+		v = 1
+	}
+	return v
+}
+
+// pickRoomType chooses the type of room i (1-based) out of roomCount. Rest
+// rooms get rarer deeper in, and long runs of dangerous rooms are broken up so
+// the party is never pushed through a wall of fights with no chance to recover.
+func pickRoomType(r *rand.Rand, i, roomCount int, prev []string) string {
+	depth := 0.0
+	if roomCount > 1 {
+		depth = float64(i-1) / float64(roomCount-1)
+	}
+
+	// Three dangerous rooms in a row forces a breather.
+	danger := 0
+	for j := len(prev) - 1; j >= 0 && (prev[j] == "combat" || prev[j] == "trap"); j-- {
+		danger++
+	}
+	if danger >= 3 {
+		return "rest"
+	}
+	combatStreak := 0
+	for j := len(prev) - 1; j >= 0 && prev[j] == "combat"; j-- {
+		combatStreak++
+	}
+
+	weights := []struct {
+		t string
+		w int
+	}{
+		{"loot", 25},
+		{"combat", 25 + int(10*depth)},
+		{"trap", 25},
+		{"rest", max(25-int(17*depth), 8)},
+	}
+	if combatStreak >= 2 {
+		weights[1].w = 0
+	}
+	// Back-to-back rests are wasted rooms.
+	if len(prev) > 0 && prev[len(prev)-1] == "rest" {
+		weights[3].w /= 3
+	}
+
+	total := 0
+	for _, wt := range weights {
+		total += wt.w
+	}
+	n := r.Intn(total)
+	for _, wt := range weights {
+		if n < wt.w {
+			return wt.t
+		}
+		n -= wt.w
+	}
+	return "loot"
+}
@@ -0,0 +1,71 @@
+package main
+
+import "testing"
+
+func TestRoomLayout(t *testing.T) {
+	for _, difficulty := range []string{"easy", "normal", "hard"} {
+		t.Run(difficulty, func(t *testing.T) {
+			var earlyRests, lateRests, early, late int
+			for seed := int64(1); seed <= 500; seed++ {
+				w := buildWorld("a dark dungeon", "dungeon", "dark", "2D", difficulty, seed)
+				danger, combat := 0, 0
+				for i, rm := range w.Rooms {
+					switch rm.Type {
+					case "combat":
+						danger++
+						combat++
+					case "trap":
+						danger++
+						combat = 0
+					default:
+						danger, combat = 0, 0
+					}
+					if danger > 3 {
+						t.Fatalf("seed %d: more than three dangerous rooms in a row ending at room %d", seed, rm.Index)
+					}
+					if combat >= 3 {
+						t.Fatalf("seed %d: three combat rooms in a row ending at room %d", seed, rm.Index)
+					}
+					switch third := i * 3 / len(w.Rooms); third {
+					case 0:
+						early++
+						if rm.Type == "rest" {
+							earlyRests++
+						}
+					case 2:
+						late++
+						if rm.Type == "rest" {
+							lateRests++
+						}
+					}
+				}
+			}
+			earlyShare := float64(earlyRests) / float64(early)
+			lateShare := float64(lateRests) / float64(late)
+			if lateShare >= earlyShare {
+				t.Errorf("rests don't thin out with depth: %.2f of early rooms, %.2f of late rooms", earlyShare, lateShare)
+			}
+		})
+	}
+}
+
+func TestDangerScale(t *testing.T) {
+	tests := []struct {
+		easier, harder string
+	}{
+		{"easy", "normal"},
+		{"normal", "hard"},
+	}
+	for _, tt := range tests {
+		easy := buildWorld("a dark dungeon", "dungeon", "dark", "2D", tt.easier, 7)
+		hard := buildWorld("a dark dungeon", "dungeon", "dark", "2D", tt.harder, 7)
+		for i := 1; i <= len(easy.Rooms); i++ {
+			if i > 1 && dangerScale(easy, i) <= dangerScale(easy, i-1) {
+				t.Errorf("%s: danger doesn't grow from room %d to %d", tt.easier, i-1, i)
+			}
+			if dangerScale(hard, i) <= dangerScale(easy, i) {
+				t.Errorf("room %d: %s isn't more dangerous than %s", i, tt.harder, tt.easier)
+			}
+		}
+	}
+}
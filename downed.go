@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// A hero at 0 HP is downed rather than gone. Downed heroes bleed out after a
+// few rooms unless the player spends a move on a revive: a standing hero with
+// the revive ability pulls them up, or else a revive item does. With
+// permadeath on, a hero that bleeds out is dead for good; otherwise a revive
+// item can still bring them back.
+
+const (
+	bleedOutRooms = 3
+	reviveItem    = "potion of revival"
+)
+
+func isDowned(h Hero) bool { return h.Status == "downed" }
+func isDead(h Hero) bool   { return h.Status == "dead" }
+
+// knockDown is called whenever damage drops a hero to 0 HP.
+func knockDown(w *World, h *Hero) {
+	h.HP = 0
+	if h.Status != "" {
+		return
+	}
+	h.Status = "downed"
+	h.BleedOut = bleedOutRooms
+	emit(w, LogEvent{Type: "downed", Target: h.Name, Value: h.BleedOut})
+}
+
+func standing(w *World) int {
+	n := 0
+	for _, h := range w.Party {
+		if h.HP > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// reviveHero is the revive move. A standing hero with the revive ability
+// pulls a downed ally up; otherwise a revive item is spent. Dead heroes can
+// only be revived by an item, and only when permadeath is off.
+func reviveHero(w *World, name string) error {
+	var h *Hero
+	for i := range w.Party {
+		if w.Party[i].Name == name {
+			h = &w.Party[i]
+		}
+	}
+	if h == nil {
+		return apierr.Invalid("hero", "no hero named %q", name)
+	}
+	if isDead(*h) && w.Permadeath {
+		return fmt.Errorf("%s is gone for good", h.Name)
+	}
+	if !isDowned(*h) && !isDead(*h) {
+		return fmt.Errorf("%s is not down", h.Name)
+	}
+	if isDowned(*h) {
+		for i := range w.Party {
+			if hasAbility(w.Party[i], "revive") && w.Party[i].HP > 0 {
+				revive(w, h, w.Party[i].Name)
+				return nil
+			}
+		}
+	}
+	if !takeItem(w, reviveItem) {
+		return fmt.Errorf("no one can revive %s without a %s", h.Name, reviveItem)
+	}
+	revive(w, h, "a "+reviveItem)
+	return nil
+}
+
+func revive(w *World, h *Hero, by string) {
+	h.Status = ""
+	h.BleedOut = 0
+	h.HP = max(h.MaxHP/4, 1)
+	w.Morale = clampMeter(w.Morale + 5)
+	emit(w, LogEvent{Type: "revived", Actor: by, Target: h.Name, Value: h.HP})
+}
+
+// tickDowned advances bleed-out timers after a room. Rest rooms stabilise the
+// wounded, so timers hold there.
+func tickDowned(w *World, room Room) {
+	if room.Type == "rest" {
+		return
+	}
+	for i := range w.Party {
+		h := &w.Party[i]
+		if !isDowned(*h) {
+			continue
+		}
+		h.BleedOut--
+		if h.BleedOut > 0 {
+			continue
+		}
+		h.Status = "dead"
+		h.BleedOut = 0
+		fate := "Only a " + reviveItem + " can bring them back."
+		if w.Permadeath {
+			fate = "They are gone for good."
+		}
+		emit(w, LogEvent{Type: "bled_out", Target: h.Name, Detail: fate})
+	}
+}
+
+func takeItem(w *World, item string) bool {
+	for i, it := range w.Inventory {
+		if it == item {
+			w.Inventory = append(w.Inventory[:i], w.Inventory[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
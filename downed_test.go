@@ -0,0 +1,101 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// downedWorld is a world of loot rooms whose tank has just gone down next to a
+// standing healer.
+func downedWorld(t *testing.T) *World {
+	t.Helper()
+	loadClasses()
+	w := buildWorld("a dark dungeon", "dungeon", "dark", "2D", "normal", 11)
+	for i := range w.Rooms {
+		w.Rooms[i].Type = "loot"
+	}
+	party, err := generateParty(w.Theme, []string{"tank", "healer"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Party = party
+	knockDown(w, &w.Party[0])
+	return w
+}
+
+func TestBleedOut(t *testing.T) {
+	w := downedWorld(t)
+	for moves := 0; isDowned(w.Party[0]); moves++ {
+		if moves > 2*bleedOutRooms {
+			t.Fatal("the tank never bled out")
+		}
+		m := Move{Action: "explore"}
+		if w.Event != nil {
+			m = Move{Action: "choose", Choice: w.Event.Choices[0].ID}
+		}
+		if err := play(w, m); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !isDead(w.Party[0]) {
+		t.Errorf("tank is %q, want dead", w.Party[0].Status)
+	}
+	if w.Party[1].HP <= 0 {
+		t.Error("the healer went down too")
+	}
+}
+
+func TestReviveMove(t *testing.T) {
+	tests := []struct {
+		name    string
+		setup   func(w *World)
+		by      string // "" when the revive fails
+		invLeft int
+	}{
+		{"healer", func(w *World) {}, "Healer", 0},
+		{"item", func(w *World) {
+			knockDown(w, &w.Party[1])
+			w.Inventory = []string{reviveItem}
+		}, "a " + reviveItem, 0},
+		{"nobody", func(w *World) { knockDown(w, &w.Party[1]) }, "", 0},
+		{"not down", func(w *World) { revive(w, &w.Party[0], "test") }, "", 0},
+		{"permadeath", func(w *World) {
+			w.Permadeath = true
+			w.Party[0].Status = "dead"
+			w.Inventory = []string{reviveItem}
+		}, "", 1},
+	}
+	for _, tt := range tests {
+		w := downedWorld(t)
+		tt.setup(w)
+		moves, events := w.Moves, len(w.Events)
+		err := play(w, Move{Action: "revive", Hero: w.Party[0].Name})
+		if tt.by == "" {
+			if err == nil {
+				t.Errorf("%s: revive succeeded", tt.name)
+			}
+			if w.Moves != moves {
+				t.Errorf("%s: a refused revive cost a move", tt.name)
+			}
+		} else {
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if w.Party[0].Status != "" || w.Party[0].HP <= 0 {
+				t.Errorf("%s: tank is %q with %d HP", tt.name, w.Party[0].Status, w.Party[0].HP)
+			}
+			if last := w.Events[len(w.Events)-1]; last.Type != "revived" || !strings.HasPrefix(last.Actor, tt.by) {
+				t.Errorf("%s: last event %+v, want revived by %s", tt.name, last, tt.by)
+			}
+			if w.Moves != moves+1 || w.Current != 0 {
+				t.Errorf("%s: moves %d -> %d, room %d; want one move spent in place", tt.name, moves, w.Moves, w.Current)
+			}
+		}
+		if tt.by == "" && len(w.Events) != events {
+			t.Errorf("%s: a refused revive emitted events", tt.name)
+		}
+		if len(w.Inventory) != tt.invLeft {
+			t.Errorf("%s: %d items left, want %d", tt.name, len(w.Inventory), tt.invLeft)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// asAPIError returns err as an *apierr.Error. Errors from the game rules that
+// aren't one already are reported as invalid_action.
+func asAPIError(err error) *apierr.Error {
+	var e *apierr.Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return apierr.New(apierr.InvalidAction, "%s", err.Error())
+}
+
+// writeError answers with the shared error envelope.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	apierr.Write(w, r, asAPIError(err))
+}
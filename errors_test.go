@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// TestUnrouted checks that requests no route takes get the error envelope, not
+// ServeMux's or FileServer's plain text.
+func TestUnrouted(t *testing.T) {
+	saved := cfg
+	cfg.DataDir, cfg.AssetsDir = t.TempDir(), t.TempDir()
+	defer func() { cfg = saved }()
+	if err := os.MkdirAll(assetPath("web"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(assetPath("web", "hello.txt"), []byte("hi"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	mux := routes()
+
+	tests := []struct {
+		method, path string
+		status       int
+		code, allow  string
+	}{
+		{"GET", "/nowhere", http.StatusNotFound, apierr.NotFound, ""},
+		{"GET", "/worlds/w1/nowhere", http.StatusNotFound, apierr.NotFound, ""},
+		{"GET", "/web/missing.html", http.StatusNotFound, apierr.NotFound, ""},
+		{"PUT", "/worlds", http.StatusMethodNotAllowed, apierr.MethodNotAllowed, "GET, POST"},
+		{"POST", "/worlds/w1", http.StatusMethodNotAllowed, apierr.MethodNotAllowed, "GET, DELETE"},
+		{"DELETE", "/", http.StatusMethodNotAllowed, apierr.MethodNotAllowed, "GET"},
+		{"GET", "/", http.StatusOK, "", ""},
+		{"GET", "/web/hello.txt", http.StatusOK, "", ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d", tt.method, tt.path, rec.Code, tt.status)
+			continue
+		}
+		if allow := rec.Header().Get("Allow"); allow != tt.allow {
+			t.Errorf("%s %s: Allow %q, want %q", tt.method, tt.path, allow, tt.allow)
+		}
+		if tt.code == "" {
+			continue
+		}
+		var body struct {
+			Error apierr.Error `json:"error"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error.Code != tt.code {
+			t.Errorf("%s %s: body %q, want a %s envelope", tt.method, tt.path, rec.Body, tt.code)
+		}
+	}
+}
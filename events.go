@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// Random encounters fire on the way between rooms. Each world carries a
+// seeded deck of event kinds; when an encounter fires the next card is
+// drawn and the party must pick one of its choices before moving on.
+
+type Event struct {
+	Kind    string        `json:"kind"`
+	Text    string        `json:"text"`
+	Choices []EventChoice `json:"choices"`
+}
+
+type EventChoice struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+const eventChance = 25 // percent per transition
+
+var eventKinds = []string{"merchant", "ambush", "stranger", "shrine"}
+
+func shuffledDeck(r *rand.Rand) []string {
+	deck := append([]string(nil), eventKinds...)
+	r.Shuffle(len(deck), func(i, j int) { deck[i], deck[j] = deck[j], deck[i] })
+	return deck
+}
+
+// maybeDrawEvent rolls for an encounter on the way to room w.Current.
+func maybeDrawEvent(w *World) {
+	if w.Event != nil || w.Current >= len(w.Rooms) || standing(w) == 0 {
+		return
+	}
+	r := rand.New(rand.NewSource(w.Seed ^ int64(w.Current*104729+len(w.Log))))
+	if r.Intn(100) >= eventChance {
+		return
+	}
+	if len(w.EventDeck) == 0 {
+		w.EventDeck = shuffledDeck(r)
+	}
+	kind := w.EventDeck[0]
+	w.EventDeck = w.EventDeck[1:]
+	w.Event = newEvent(kind, adapterFor(w.Theme))
+	emit(w, LogEvent{Type: "encounter", Actor: kind, Detail: w.Event.Text})
+}
+
+func newEvent(kind string, a themeAdapter) *Event {
+	switch kind {
+	case "merchant":
+		return &Event{Kind: kind, Text: fmt.Sprintf("In the %s you meet %s.", a.Path, a.Merchant), Choices: []EventChoice{
+			{"trade", "Trade an item for a " + reviveItem},
+			{"haggle", "Haggle for a free potion"},
+			{"ignore", "Walk on"},
+		}}
+	case "ambush":
+		return &Event{Kind: kind, Text: fmt.Sprintf("%s spring an ambush in the %s!", capitalize(a.Ambushers), a.Path), Choices: []EventChoice{
+			{"fight", "Stand and fight"},
+			{"hide", "Try to hide"},
+		}}
+	case "stranger":
+		return &Event{Kind: kind, Text: fmt.Sprintf("%s blocks the %s and offers help.", capitalize(a.Stranger), a.Path), Choices: []EventChoice{
+			{"trust", "Accept the offer"},
+			{"ask", "Ask what lies ahead"},
+			{"decline", "Politely decline"},
+		}}
+	default:
+		return &Event{Kind: "shrine", Text: fmt.Sprintf("The %s opens onto %s.", a.Path, a.Shrine), Choices: []EventChoice{
+			{"pray", "Pray at the shrine"},
+			{"desecrate", "Pry loose its offerings"},
+			{"leave", "Leave it be"},
+		}}
+	}
+}
+
+// resolveEvent applies the party's choice for the pending event.
+func resolveEvent(w *World, choice string) error {
+	ev := w.Event
+	if ev == nil {
+		return errors.New("no event is waiting for a choice")
+	}
+	valid := false
+	for _, c := range ev.Choices {
+		if c.ID == choice {
+			valid = true
+		}
+	}
+	if !valid {
+		return apierr.Invalid("choice", "choice must be one of %s", choiceIDs(ev))
+	}
+	w.Event = nil
+	r := rand.New(rand.NewSource(w.Seed + int64(w.Current)*7 + int64(len(w.Log))))
+	a := adapterFor(w.Theme)
+	outcome := func(text string) {
+		emit(w, LogEvent{Type: "event_outcome", Actor: ev.Kind, Detail: text})
+	}
+
+	switch ev.Kind + ":" + choice {
+	case "merchant:trade":
+		if len(w.Inventory) == 0 {
+			outcome("You have nothing to trade. The merchant shrugs and moves on.")
+			break
+		}
+		i := r.Intn(len(w.Inventory))
+		emit(w, LogEvent{Type: "item_traded", Actor: "merchant", Target: w.Inventory[i], Detail: reviveItem})
+		w.Inventory[i] = reviveItem
+	case "merchant:haggle":
+		if r.Intn(2) == 0 {
+			w.Inventory = append(w.Inventory, "potion of healing")
+			emit(w, LogEvent{Type: "item_gained", Actor: "The merchant", Detail: "potion of healing"})
+		} else {
+			w.Morale = clampMeter(w.Morale - 3)
+			outcome("The merchant takes offence and leaves.")
+		}
+	case "ambush:fight":
+		fight(w, r.Int63(), false)
+	case "ambush:hide":
+		if r.Intn(100) < fleeChance(w) {
+			outcome(fmt.Sprintf("The %s pass by without noticing the party.", a.Ambushers))
+		} else {
+			outcome("The party is spotted!")
+			partingShots(w, r, dangerScale(w, w.Current+1))
+		}
+	case "stranger:trust":
+		if r.Intn(3) > 0 {
+			healed := 0
+			for i := range w.Party {
+				if w.Party[i].HP <= 0 {
+					continue
+				}
+				amount := min(15, w.Party[i].MaxHP-w.Party[i].HP)
+				w.Party[i].HP += amount
+				healed += amount
+			}
+			emit(w, LogEvent{Type: "party_healed", Actor: "The stranger", Amount: healed})
+		} else if len(w.Inventory) > 0 {
+			i := r.Intn(len(w.Inventory))
+			emit(w, LogEvent{Type: "item_stolen", Actor: "The stranger", Detail: w.Inventory[i]})
+			w.Inventory = append(w.Inventory[:i], w.Inventory[i+1:]...)
+		} else {
+			outcome("The stranger finds nothing worth stealing and vanishes.")
+		}
+	case "stranger:ask":
+		next := w.Rooms[w.Current]
+		outcome(fmt.Sprintf("\"Room %d holds %s,\" the stranger whispers.", next.Index, next.Type))
+	case "shrine:pray":
+		w.Morale = clampMeter(w.Morale + 15)
+		alive := []int{}
+		for i := range w.Party {
+			if w.Party[i].HP > 0 {
+				alive = append(alive, i)
+			}
+		}
+		outcome("A cold calm settles over the party.")
+		if len(alive) > 0 {
+			h := &w.Party[alive[r.Intn(len(alive))]]
+			dmg := min(5+r.Intn(6), h.HP-1)
+			h.HP -= dmg
+			emit(w, LogEvent{Type: "hero_hurt", Actor: "The curse", Target: h.Name, Amount: dmg, Value: h.HP})
+		}
+	case "shrine:desecrate":
+		w.Inventory = append(w.Inventory, "cursed "+a.Relic)
+		w.Morale = clampMeter(w.Morale - 10)
+		outcome(fmt.Sprintf("You take a cursed %s. Unease spreads through the party.", a.Relic))
+	default:
+		outcome("The party moves on.")
+	}
+	return nil
+}
+
+func choiceIDs(ev *Event) string {
+	ids := []string{}
+	for _, c := range ev.Choices {
+		ids = append(ids, c.ID)
+	}
+	return strings.Join(ids, ", ")
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// canFlee reports why the party can't flee in direction ("" means back), or
+// nil if it can. play checks it before the move counts.
+func canFlee(w *World, direction string) error {
+	if w.Current >= len(w.Rooms) {
+		return errors.New("nothing to flee from")
+	}
+	room := w.Rooms[w.Current]
+	if room.Type != "combat" || room.State == "cleared" {
+		return errors.New("nothing to flee from")
+	}
+	if direction != "" && direction != "back" && direction != "forward" {
+		return apierr.Invalid("direction", `direction must be "back" or "forward"`)
+	}
+	if direction != "forward" && w.Current == 0 {
+		return errors.New("no room to retreat to")
+	}
+	if standing(w) == 0 {
+		return errors.New("no one is standing to flee")
+	}
+	return nil
+}
+
+// flee tries to avoid the combat room the party is about to enter. Success
+// depends on party speed. Retreating back costs nothing but may shake an item
+// loose; slipping forward past the encounter is harder and the enemies get
+// parting shots. Either way the room is left unresolved and will have to be
+// fought if the party comes through again. A failed attempt means a free
+// round of hits for the enemy before the fight plays out as usual. Callers
+// check canFlee first.
+func flee(w *World, direction string) {
+	if direction == "" {
+		direction = "back"
+	}
+	room := &w.Rooms[w.Current]
+	r := rand.New(rand.NewSource(w.Seed + int64(w.Current)*7919 + int64(len(w.Log))))
+	scale := dangerScale(w, room.Index)
+	chance := fleeChance(w)
+	if direction == "forward" {
+		chance -= 15
+	}
+	w.here = room.Index
+	emit(w, LogEvent{Type: "flee_attempt", Amount: chance, Detail: direction})
+
+	if r.Intn(100) >= chance {
+		emit(w, LogEvent{Type: "flee_failed"})
+		partingShots(w, r, scale)
+		resolveCombat(w, w.Seed+int64(w.Current))
+		room.State = "cleared"
+		w.Current++
+		return
+	}
+
+	room.State = "unresolved"
+	if direction == "back" {
+		if len(w.Inventory) > 0 && r.Intn(2) == 0 {
+			i := r.Intn(len(w.Inventory))
+			emit(w, LogEvent{Type: "item_dropped", Detail: w.Inventory[i]})
+			w.Inventory = append(w.Inventory[:i], w.Inventory[i+1:]...)
+		}
+		w.Current--
+		w.here = w.Rooms[w.Current].Index
+		emit(w, LogEvent{Type: "fell_back"})
+		return
+	}
+	partingShots(w, r, scale)
+	w.Current++
+	if w.Current < len(w.Rooms) {
+		w.Rooms[w.Current].Discovered = true
+	}
+	emit(w, LogEvent{Type: "dashed_past"})
+}
+
+// fleeChance is the percent chance of a clean escape, driven by the average
+// dexterity of everyone still standing.
+func fleeChance(w *World) int {
+	dex, n := 0, 0
+	for _, h := range w.Party {
+		if h.HP <= 0 {
+			continue
+		}
+		dex += 5 + stat(h, "dex")
+		n++
+	}
+	if n == 0 {
+		return 0
+	}
+	chance := 20 + 6*dex/n
+	if chance > 90 {
+		chance = 90
+	}
+	return chance
+}
+
+func partingShots(w *World, r *rand.Rand, scale float64) {
+	for i := range w.Party {
+		if w.Party[i].HP <= 0 {
+			continue
+		}
+		hit := scaled(2+r.Intn(5), scale)
+		w.Party[i].HP -= hit
+		if w.Party[i].HP < 0 {
+			w.Party[i].HP = 0
+		}
+		emit(w, LogEvent{Type: "struck", Target: w.Party[i].Name, Amount: hit, Value: w.Party[i].HP})
+		if w.Party[i].HP == 0 {
+			knockDown(w, &w.Party[i])
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import "net/http"
+
+// Fog of war: players only see rooms they have discovered. The first room is
+// discovered from the start; entering a room marks it visited and reveals the
+// doorway to the next one. Handlers return playerView; the full world is only
+// available through the debug view.
+
+func discover(w *World, i int) {
+	if i < 0 || i >= len(w.Rooms) {
+		return
+	}
+	w.Rooms[i].Visited = true
+	w.Rooms[i].Discovered = true
+	if i+1 < len(w.Rooms) {
+		w.Rooms[i+1].Discovered = true
+	}
+}
+
+// playerView returns a copy of w with undiscovered rooms, the NPCs inside them,
+// the event deck and the seed stripped out. The copy shares slices with w, so callers
+// hold worldMu until they have marshalled it.
+func playerView(w *World) *World {
+	v := *w
+	v.Rooms = make([]Room, len(w.Rooms))
+	hidden := map[int]bool{}
+	for i, rm := range w.Rooms {
+		if rm.Discovered {
+			v.Rooms[i] = rm
+			continue
+		}
+		hidden[rm.Index] = true
+		v.Rooms[i] = Room{Index: rm.Index, Type: "unknown", TrapKnown: rm.TrapKnown}
+		if rm.TrapKnown {
+			v.Rooms[i].Type = "trap"
+		}
+	}
+	v.NPCs = []NPC{}
+	for _, n := range w.NPCs {
+		if !hidden[n.Room] {
+			v.NPCs = append(v.NPCs, n)
+		}
+	}
+	v.EventDeck = nil
+	v.Seed = 0
+	return &v
+}
+
+// isDebugRequest checks X-Debug-Token against the configured debug token,
+// which unlocks ?view=debug. The debug view is disabled when it is unset.
+func isDebugRequest(r *http.Request) bool {
+	return cfg.DebugToken != "" && r.Header.Get("X-Debug-Token") == cfg.DebugToken
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSeedHidden(t *testing.T) {
+	saved := cfg
+	cfg.DataDir = t.TempDir()
+	cfg.DebugToken = "sesame"
+	defer func() { cfg = saved }()
+	if err := os.MkdirAll(dataPath("worlds"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	loadClasses()
+
+	srv := httptest.NewServer(routes())
+	defer srv.Close()
+	wld := createWorld(worldOptions{Prompt: "a dark dungeon"})
+	defer func() {
+		storeMu.Lock()
+		delete(store, wld.ID)
+		storeMu.Unlock()
+	}()
+	if wld.ID == strconv.FormatInt(wld.Seed, 10) {
+		t.Error("the world ID is its seed")
+	}
+
+	get := func(path, token string) (*http.Response, string) {
+		req, _ := http.NewRequest("GET", srv.URL+path, nil)
+		if token != "" {
+			req.Header.Set("X-Debug-Token", token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp, string(body)
+	}
+
+	_, body := get("/worlds/"+wld.ID, "")
+	var view map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(body), &view); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := view["seed"]; ok {
+		t.Error("the player view has the seed")
+	}
+	if strings.Contains(body, strconv.FormatInt(wld.Seed, 10)) {
+		t.Error("the seed shows up somewhere in the player view")
+	}
+
+	_, body = get("/api/latest-world", "")
+	if !strings.Contains(body, `"`+wld.ID+`"`) {
+		t.Errorf("latest world = %s, want id %s", body, wld.ID)
+	}
+
+	file := "/worlds/world_" + wld.ID + ".json"
+	if resp, body := get(file, ""); resp.StatusCode == http.StatusOK || strings.Contains(body, `"seed"`) {
+		t.Errorf("save file without a token: %s", resp.Status)
+	}
+}
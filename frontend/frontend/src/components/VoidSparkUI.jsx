@@ -1,12 +1,49 @@
 // src/components/VoidSparkUI.jsx
-import React, { useState } from "react";
+import React, { useEffect, useRef, useState } from "react";
 
-const BACKEND = "http://127.0.0.1:8080";
+// The UI talks to the game server on its own origin; vite.config.ts proxies
+// the API there in development.
+const BACKEND = "";
+const BACKEND_WS = `${location.protocol === "https:" ? "wss" : "ws"}://${location.host}/ws`;
 
 export default function VoidSparkUI() {
   const [prompt, setPrompt] = useState("a neon cyberpunk city with drone taxis and hacker dens");
   const [worldId, setWorldId] = useState("");
   const [log, setLog] = useState("");
+  const [events, setEvents] = useState([]);
+  const socket = useRef(null);
+
+  // Play the session over one WebSocket: actions go out as "act" messages and
+  // events and state come back as they happen.
+  useEffect(() => {
+    if (!worldId) return;
+    setEvents([]);
+    const ws = new WebSocket(BACKEND_WS);
+    ws.onopen = () => ws.send(JSON.stringify({ type: "subscribe", id: worldId }));
+    ws.onmessage = (e) => {
+      const msg = JSON.parse(e.data);
+      if (msg.type === "event") setEvents((prev) => [...prev, msg.event]);
+      if (msg.type === "state") setLog(JSON.stringify(msg.state, null, 2));
+      if (msg.type === "error") setLog(`❌ ${msg.error.message}`);
+    };
+    socket.current = ws;
+    return () => {
+      socket.current = null;
+      ws.close();
+    };
+  }, [worldId]);
+
+  // act sends an action over the socket, falling back to HTTP while it is not
+  // open yet.
+  const act = async (path, body) => {
+    const ws = socket.current;
+    if (ws && ws.readyState === WebSocket.OPEN) {
+      ws.send(JSON.stringify({ type: "act", ...body }));
+      return;
+    }
+    const r = await apiPost(path, { id: worldId, ...body });
+    setLog(r.ok ? JSON.stringify(r.data, null, 2) : `❌ ${r.error}`);
+  };
 
   const apiPost = async (path, body) => {
     try {
@@ -16,7 +53,7 @@ export default function VoidSparkUI() {
         body: JSON.stringify(body),
       });
       const data = await res.json();
-      if (!res.ok) throw new Error(data.error || `HTTP ${res.status}`);
+      if (!res.ok) throw new Error(data.error?.message || `HTTP ${res.status}`);
       return { ok: true, data };
     } catch (err) {
       return { ok: false, error: err.message };
@@ -30,25 +67,14 @@ export default function VoidSparkUI() {
     setLog(JSON.stringify(r.data, null, 2));
   };
 
-  const refine = async () => {
-    if (!worldId) return setLog("⚠️ Generate first");
-    const r = await apiPost("/refine", { id: worldId, prompt });
-    if (!r.ok) return setLog(`❌ Refine failed: ${r.error}`);
-    setLog(JSON.stringify(r.data, null, 2));
-  };
-
   const addAgents = async () => {
     if (!worldId) return setLog("⚠️ Generate first");
-    const r = await apiPost("/party", { id: worldId });
-    if (!r.ok) return setLog(`❌ Agents failed: ${r.error}`);
-    setLog(JSON.stringify(r.data, null, 2));
+    await act("/party", { action: "party" });
   };
 
   const explore = async () => {
     if (!worldId) return setLog("⚠️ Generate first");
-    const r = await apiPost("/explore", { id: worldId });
-    if (!r.ok) return setLog(`❌ Explore failed: ${r.error}`);
-    setLog(JSON.stringify(r.data, null, 2));
+    await act("/explore", { action: "explore" });
   };
 
   const showState = async () => {
@@ -56,7 +82,7 @@ export default function VoidSparkUI() {
     try {
       const res = await fetch(`${BACKEND}/state?id=${worldId}`);
       const data = await res.json();
-      if (!res.ok) throw new Error(data.error || `HTTP ${res.status}`);
+      if (!res.ok) throw new Error(data.error?.message || `HTTP ${res.status}`);
       setLog(JSON.stringify(data, null, 2));
     } catch (e) {
       setLog(`❌ State failed: ${e.message}`);
@@ -64,7 +90,7 @@ export default function VoidSparkUI() {
   };
 
   const openPreview = () => {
-    window.open(`${BACKEND}/data/web/preview/world_preview.html`, "_blank");
+    window.open(`${BACKEND}/web/preview/world_preview.html`, "_blank");
   };
 
   return (
@@ -85,9 +111,6 @@ export default function VoidSparkUI() {
           <button onClick={generate} className="px-4 py-2 bg-cyan-600 rounded">
             Generate World
           </button>
-          <button onClick={refine} disabled={!worldId} className="px-4 py-2 bg-purple-600 rounded">
-            Refine World
-          </button>
           <button onClick={addAgents} disabled={!worldId} className="px-4 py-2 bg-green-600 rounded">
             Add Agents
           </button>
@@ -102,6 +125,12 @@ export default function VoidSparkUI() {
         <pre className="mt-4 bg-gray-800 p-3 rounded text-xs h-64 overflow-auto font-mono">
           {log || "Output appears here..."}
         </pre>
+
+        {events.length > 0 && (
+          <pre className="mt-4 bg-gray-800 p-3 rounded text-xs h-40 overflow-auto font-mono">
+            {events.map((ev) => `#${ev.seq} ${ev.text}`).join("\n")}
+          </pre>
+        )}
       </div>
 
       <div className="mt-4 text-center">
@@ -28,5 +28,12 @@ export default defineConfig(async () => ({
       // 3. tell Vite to ignore watching `src-tauri`
       ignored: ["**/src-tauri/**"],
     },
+    // 4. send API calls and the /ws socket to the game server
+    proxy: Object.fromEntries(
+      ["/generate", "/party", "/explore", "/state", "/web", "/ws"].map((path) => [
+        path,
+        { target: "http://127.0.0.1:8080", ws: path === "/ws" },
+      ]),
+    ),
   },
 }));
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// Heroes have three equipment slots. Loot that matches an entry in
+// gearCatalog can be equipped from the world inventory; its stat mods stack on
+// the hero's base stats, and combat, flee and trap formulas all read the
+// result through effectiveStats.
+
+type Gear struct {
+	Slot string         `json:"slot"`
+	Mods map[string]int `json:"mods"`
+}
+
+var gearSlots = []string{"weapon", "armor", "accessory"}
+
+var gearCatalog = map[string]Gear{
+	"rusty sword":     {"weapon", map[string]int{"str": 2}},
+	"twin daggers":    {"weapon", map[string]int{"str": 1, "dex": 2}},
+	"runed staff":     {"weapon", map[string]int{"int": 3}},
+	"iron buckler":    {"armor", map[string]int{"def": 3}},
+	"leather jerkin":  {"armor", map[string]int{"def": 2, "dex": 1}},
+	"sapphire amulet": {"accessory", map[string]int{"int": 3}},
+	"weird trinket":   {"accessory", map[string]int{"dex": 2, "def": -1}},
+}
+
+// effectiveStats returns a hero's stats with equipment applied.
+func effectiveStats(h Hero) map[string]int {
+	stats := map[string]int{}
+	for k, v := range h.Stats {
+		stats[k] = v
+	}
+	for _, item := range h.Equipment {
+		for k, v := range gearCatalog[item].Mods {
+			stats[k] += v
+		}
+	}
+	return stats
+}
+
+func stat(h Hero, name string) int { return effectiveStats(h)[name] }
+
+// equip moves item from the inventory into hero's matching slot, returning
+// whatever was there to the inventory.
+func equip(w *World, h *Hero, item string) error {
+	g, ok := gearCatalog[item]
+	if !ok {
+		return apierr.Invalid("item", "%s is not equippable", item)
+	}
+	if !takeItem(w, item) {
+		return fmt.Errorf("no %s in the inventory", item)
+	}
+	if h.Equipment == nil {
+		h.Equipment = map[string]string{}
+	}
+	detail := fmt.Sprintf("%s (%s)", item, describeMods(g.Mods))
+	if old := h.Equipment[g.Slot]; old != "" {
+		w.Inventory = append(w.Inventory, old)
+		detail += ", stowing the " + old
+	}
+	h.Equipment[g.Slot] = item
+	emit(w, LogEvent{Type: "equipped", Actor: h.Name, Detail: detail})
+	return nil
+}
+
+func unequip(w *World, h *Hero, slot string) error {
+	valid := false
+	for _, s := range gearSlots {
+		valid = valid || s == slot
+	}
+	if !valid {
+		return apierr.Invalid("slot", "slot must be one of %s", strings.Join(gearSlots, ", "))
+	}
+	item := h.Equipment[slot]
+	if item == "" {
+		return fmt.Errorf("%s has nothing in the %s slot", h.Name, slot)
+	}
+	delete(h.Equipment, slot)
+	w.Inventory = append(w.Inventory, item)
+	emit(w, LogEvent{Type: "unequipped", Actor: h.Name, Detail: item})
+	return nil
+}
+
+func describeMods(mods map[string]int) string {
+	keys := []string{}
+	for k := range mods {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := []string{}
+	for _, k := range keys {
+		parts = append(parts, fmt.Sprintf("%+d %s", mods[k], k))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// equipHandler handles POST /equip {"id", "hero", "item"} to equip an item
+// from the inventory, or {"id", "hero", "slot"} to unequip a slot.
+func equipHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		apierr.Write(w, r, apierr.New(apierr.MethodNotAllowed, "POST only"))
+		return
+	}
+	var req struct {
+		ID   string `json:"id"`
+		Hero string `json:"hero"`
+		Item string `json:"item"`
+		Slot string `json:"slot"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, r, apierr.BadBody(err))
+		return
+	}
+	storeMu.Lock()
+	wld, ok := store[req.ID]
+	storeMu.Unlock()
+	if !ok {
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "session not found"))
+		return
+	}
+	if err := equipHero(wld, req.Hero, req.Item, req.Slot); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeView(w, wld)
+}
+
+// equipHero equips item on the named hero or, with no item, empties slot.
+func equipHero(wld *World, name, item, slot string) error {
+	worldMu.Lock()
+	defer worldMu.Unlock()
+	if err := runOver(wld); err != nil {
+		return err
+	}
+	var hero *Hero
+	for i := range wld.Party {
+		if wld.Party[i].Name == name {
+			hero = &wld.Party[i]
+		}
+	}
+	if hero == nil {
+		return apierr.New(apierr.NotFound, "hero not found")
+	}
+	var err error
+	if item != "" {
+		err = equip(wld, hero, item)
+	} else {
+		err = unequip(wld, hero, slot)
+	}
+	if err != nil {
+		return err
+	}
+	publishState(wld)
+	return nil
+}
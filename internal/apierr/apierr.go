@@ -0,0 +1,164 @@
+// Package apierr is the error envelope shared by the Void Spark servers. Every
+// failed request is answered with
+//
+//	{"error": {"code": "not_found", "message": "session not found",
+//	           "fields": [...], "request_id": "..."}}
+//
+// where code is one of the constants below and never changes meaning, so
+// clients can branch on it; message is for people and may change.
+package apierr
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Error codes. Each maps to one HTTP status.
+const (
+	BadJSON            = "bad_json"            // body isn't valid JSON
+	InvalidField       = "invalid_field"       // a field or parameter is missing or malformed
+	InvalidAction      = "invalid_action"      // the move breaks the rules of the game
+	NotFound           = "not_found"           // no such world, profile, NPC or hero
+	MethodNotAllowed   = "method_not_allowed"  // wrong HTTP method for the route
+	Forbidden          = "forbidden"           // e.g. debug view without a token
+	EventPending       = "event_pending"       // an encounter needs a choice first
+	WrongRoom          = "wrong_room"          // the NPC is somewhere else
+	AlreadyExists      = "already_exists"      // profile name taken
+	AlreadyPlayed      = "already_played"      // daily challenge attempt used
+	AlreadyUnlocked    = "already_unlocked"    // meta unlock bought before
+	NotSubscribed      = "not_subscribed"      // WebSocket act before subscribe
+	RunOver            = "run_over"            // talk or equip after the run ended
+	WorldDeleted       = "world_deleted"       // followed world was deleted
+	InsufficientShards = "insufficient_shards" // can't afford a meta unlock
+	UpgradeRequired    = "upgrade_required"    // WebSocket route hit without a handshake
+	Internal           = "internal"            // a bug or an unavailable feature
+)
+
+var statusByCode = map[string]int{
+	BadJSON:            http.StatusBadRequest,
+	InvalidField:       http.StatusBadRequest,
+	InvalidAction:      http.StatusBadRequest,
+	NotFound:           http.StatusNotFound,
+	MethodNotAllowed:   http.StatusMethodNotAllowed,
+	Forbidden:          http.StatusForbidden,
+	EventPending:       http.StatusConflict,
+	WrongRoom:          http.StatusConflict,
+	AlreadyExists:      http.StatusConflict,
+	AlreadyPlayed:      http.StatusConflict,
+	AlreadyUnlocked:    http.StatusConflict,
+	NotSubscribed:      http.StatusConflict,
+	RunOver:            http.StatusConflict,
+	WorldDeleted:       http.StatusGone,
+	InsufficientShards: http.StatusPaymentRequired,
+	UpgradeRequired:    http.StatusUpgradeRequired,
+	Internal:           http.StatusInternalServerError,
+}
+
+// Codes lists every error code, sorted.
+func Codes() []string {
+	codes := make([]string, 0, len(statusByCode))
+	for c := range statusByCode {
+		codes = append(codes, c)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// FieldError says what is wrong with one request field or query parameter.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+type Error struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Status is the HTTP status for the error's code.
+func (e *Error) Status() int {
+	if s, ok := statusByCode[e.Code]; ok {
+		return s
+	}
+	return http.StatusInternalServerError
+}
+
+func New(code, format string, args ...interface{}) *Error {
+	return &Error{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Invalid reports a bad value for one field.
+func Invalid(field, format string, args ...interface{}) *Error {
+	msg := fmt.Sprintf(format, args...)
+	return &Error{Code: InvalidField, Message: msg, Fields: []FieldError{{field, msg}}}
+}
+
+func BadBody(err error) *Error {
+	return New(BadJSON, "bad json: %v", err)
+}
+
+// As returns err as an *Error, treating anything else as internal.
+func As(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	return New(Internal, "%v", err)
+}
+
+// Write sends err as the error envelope, stamped with the request's ID.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	e := *As(err)
+	e.RequestID = RequestID(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Status())
+	_ = json.NewEncoder(w).Encode(struct {
+		Error *Error `json:"error"`
+	}{&e})
+}
+
+type ctxKey struct{}
+
+// RequestID returns the ID WithRequestID gave the request.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+// WithRequestID gives every request an ID, taken from a sane X-Request-ID
+// header or made up, and echoes it in the response's X-Request-ID.
+func WithRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validID(id) {
+			var b [8]byte
+			_, _ = rand.Read(b[:])
+			id = hex.EncodeToString(b[:])
+		}
+		w.Header().Set("X-Request-ID", id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, id)))
+	})
+}
+
+func validID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,457 @@
+// Package config holds the settings shared by the Void Spark servers. Each
+// setting is read, in increasing order of precedence, from the defaults the
+// binary passes to Load, an optional config file, VOIDSPARK_* environment
+// variables and command-line flags:
+//
+//	file key         env var                     flag
+//	addr             VOIDSPARK_ADDR              -addr
+//	data_dir         VOIDSPARK_DATA_DIR          -data-dir
+//	assets_dir       VOIDSPARK_ASSETS_DIR        -assets-dir
+//	allowed_origins  VOIDSPARK_ALLOWED_ORIGINS   -allowed-origins
+//	read_timeout     VOIDSPARK_READ_TIMEOUT      -read-timeout
+//	write_timeout    VOIDSPARK_WRITE_TIMEOUT     -write-timeout
+//	idle_timeout     VOIDSPARK_IDLE_TIMEOUT      -idle-timeout
+//	shutdown_timeout VOIDSPARK_SHUTDOWN_TIMEOUT  -shutdown-timeout
+//	features         VOIDSPARK_FEATURES          -features
+//	debug_token      VOIDSPARK_DEBUG_TOKEN       -debug-token
+//
+// The file is named by -config or VOIDSPARK_CONFIG. It is either a JSON object
+// or "key = value" lines, with # comments and optional quotes. Lists are comma
+// separated, timeouts are Go durations ("15s") and features are name=bool
+// pairs ("ws=false,sse=true"; a JSON file may use an object instead). Both
+// servers read the same environment and may share a file, so features a
+// binary doesn't have are skipped there with a log line; -features still
+// rejects them.
+//
+// Relative data_dir and assets_dir values never depend on the directory the
+// server happens to be started from: defaults are resolved against the
+// directory holding the executable and file values against the config file's
+// directory. Only env vars and flags, typed in a shell, are taken relative to
+// the working directory. Under go run the executable lives in a temporary
+// directory, so pass both there.
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type Config struct {
+	Addr    string // listen address, e.g. ":8080"
+	DataDir string // saved worlds, scores and profiles; made absolute by Load
+	// AssetsDir holds the files a server ships with, such as web/ and
+	// catalog/; made absolute by Load.
+	AssetsDir string
+	// AllowedOrigins may call the API from a browser; "*" allows any.
+	AllowedOrigins []string
+	ReadTimeout    time.Duration
+	WriteTimeout   time.Duration
+	IdleTimeout    time.Duration
+	// ShutdownTimeout bounds how long in-flight requests get to finish once
+	// the server is asked to stop.
+	ShutdownTimeout time.Duration
+	// Features switches optional parts of a server on and off. Each binary
+	// decides which names exist by listing them in its defaults.
+	Features   map[string]bool
+	DebugToken string // unlocks ?view=debug; empty disables it
+	File       string // config file read, if any
+}
+
+// Defaults are the settings common to both servers.
+func Defaults() Config {
+	return Config{
+		Addr:            ":8080",
+		DataDir:         ".",
+		AssetsDir:       ".",
+		AllowedOrigins:  []string{"*"},
+		ReadTimeout:     15 * time.Second,
+		WriteTimeout:    30 * time.Second,
+		IdleTimeout:     60 * time.Second,
+		ShutdownTimeout: 10 * time.Second,
+		Features:        map[string]bool{},
+	}
+}
+
+// Enabled reports whether a feature is switched on.
+func (c Config) Enabled(feature string) bool { return c.Features[feature] }
+
+type setting struct {
+	key  string
+	help string
+	set  func(c *Config, v string) error
+	show func(c Config) string
+}
+
+var settings = []setting{
+	{"addr", "listen address", func(c *Config, v string) error {
+		c.Addr = v
+		return nil
+	}, func(c Config) string { return c.Addr }},
+	{"data_dir", "directory for saved worlds, scores and profiles", func(c *Config, v string) error {
+		c.DataDir = v
+		return nil
+	}, func(c Config) string { return c.DataDir }},
+	{"assets_dir", "directory holding the web/ and catalog/ files the server ships with", func(c *Config, v string) error {
+		c.AssetsDir = v
+		return nil
+	}, func(c Config) string { return c.AssetsDir }},
+	{"allowed_origins", "comma-separated browser origins allowed to call the API, or *", func(c *Config, v string) error {
+		c.AllowedOrigins = splitList(v)
+		return nil
+	}, func(c Config) string { return strings.Join(c.AllowedOrigins, ",") }},
+	{"read_timeout", "limit for reading a request", durationSetter(func(c *Config) *time.Duration { return &c.ReadTimeout }),
+		func(c Config) string { return c.ReadTimeout.String() }},
+	{"write_timeout", "limit for writing a response (streams are exempt)", durationSetter(func(c *Config) *time.Duration { return &c.WriteTimeout }),
+		func(c Config) string { return c.WriteTimeout.String() }},
+	{"idle_timeout", "how long idle keep-alive connections stay open", durationSetter(func(c *Config) *time.Duration { return &c.IdleTimeout }),
+		func(c Config) string { return c.IdleTimeout.String() }},
+	{"shutdown_timeout", "how long in-flight requests may take to finish on shutdown", durationSetter(func(c *Config) *time.Duration { return &c.ShutdownTimeout }),
+		func(c Config) string { return c.ShutdownTimeout.String() }},
+	{"features", "feature switches, e.g. ws=false,sse=true", setFeatures, showFeatures},
+	{"debug_token", "token for ?view=debug", func(c *Config, v string) error {
+		c.DebugToken = v
+		return nil
+	}, func(c Config) string {
+		if c.DebugToken == "" {
+			return "(unset)"
+		}
+		return "(set)"
+	}},
+}
+
+func durationSetter(field func(c *Config) *time.Duration) func(c *Config, v string) error {
+	return func(c *Config, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			return fmt.Errorf("%q is not a duration", v)
+		}
+		*field(c) = d
+		return nil
+	}
+}
+
+func setFeatures(c *Config, v string) error {
+	for _, pair := range splitList(v) {
+		name, val, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		on := true
+		if ok {
+			b, err := strconv.ParseBool(strings.TrimSpace(val))
+			if err != nil {
+				return fmt.Errorf("feature %s: %q is not a bool", name, val)
+			}
+			on = b
+		}
+		if _, known := c.Features[name]; !known {
+			return fmt.Errorf("unknown feature %q (known: %s)", name, strings.Join(featureNames(*c), ", "))
+		}
+		c.Features[name] = on
+	}
+	return nil
+}
+
+func showFeatures(c Config) string {
+	out := []string{}
+	for _, name := range featureNames(c) {
+		out = append(out, fmt.Sprintf("%s=%t", name, c.Features[name]))
+	}
+	return strings.Join(out, ",")
+}
+
+func featureNames(c Config) []string {
+	names := []string{}
+	for name := range c.Features {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// knownFeatures drops the feature switches in v that c doesn't have, logging
+// each one with the source it came from.
+func knownFeatures(c Config, v, source string) string {
+	keep := []string{}
+	for _, pair := range splitList(v) {
+		name, _, _ := strings.Cut(pair, "=")
+		if _, known := c.Features[strings.TrimSpace(name)]; !known {
+			log.Printf("config: %s: skipping feature %q, which this server doesn't have", source, strings.TrimSpace(name))
+			continue
+		}
+		keep = append(keep, pair)
+	}
+	return strings.Join(keep, ",")
+}
+
+func splitList(v string) []string {
+	out := []string{}
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+func envName(key string) string  { return "VOIDSPARK_" + strings.ToUpper(key) }
+func flagName(key string) string { return strings.ReplaceAll(key, "_", "-") }
+
+// Load builds the config for a binary from its defaults and args (usually
+// os.Args[1:]). -h prints the flags and exits.
+func Load(name string, defaults Config, args []string) (Config, error) {
+	c := defaults
+	c.AllowedOrigins = slices.Clone(defaults.AllowedOrigins)
+	c.Features = map[string]bool{}
+	for k, v := range defaults.Features {
+		c.Features[k] = v
+	}
+
+	// base is what each relative directory is resolved against; "" means
+	// the working directory.
+	exeDir, err := executableDir()
+	if err != nil {
+		return c, err
+	}
+	base := map[string]string{"data_dir": exeDir, "assets_dir": exeDir}
+
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	file := fs.String("config", os.Getenv("VOIDSPARK_CONFIG"), "config file (JSON or key = value lines)")
+	var flagged []func() error
+	for _, s := range settings {
+		fs.Func(flagName(s.key), s.help+" (default "+s.show(defaults)+")", func(v string) error {
+			flagged = append(flagged, func() error {
+				if err := s.set(&c, v); err != nil {
+					return fmt.Errorf("-%s: %w", flagName(s.key), err)
+				}
+				if _, ok := base[s.key]; ok {
+					base[s.key] = ""
+				}
+				return nil
+			})
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return c, err
+	}
+
+	if *file != "" {
+		values, err := readFile(*file)
+		if err != nil {
+			return c, err
+		}
+		for _, s := range settings {
+			if v, ok := values[s.key]; ok {
+				if s.key == "features" {
+					v = knownFeatures(c, v, *file)
+				}
+				if err := s.set(&c, v); err != nil {
+					return c, fmt.Errorf("%s: %s: %w", *file, s.key, err)
+				}
+				if _, ok := base[s.key]; ok {
+					base[s.key] = filepath.Dir(*file)
+				}
+				delete(values, s.key)
+			}
+		}
+		for key := range values {
+			return c, fmt.Errorf("%s: unknown setting %q", *file, key)
+		}
+		c.File = *file
+	}
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(envName(s.key)); ok {
+			if s.key == "features" {
+				v = knownFeatures(c, v, envName(s.key))
+			}
+			if err := s.set(&c, v); err != nil {
+				return c, fmt.Errorf("%s: %w", envName(s.key), err)
+			}
+			if _, ok := base[s.key]; ok {
+				base[s.key] = ""
+			}
+		}
+	}
+	for _, apply := range flagged {
+		if err := apply(); err != nil {
+			return c, err
+		}
+	}
+
+	if c.Addr == "" {
+		return c, fmt.Errorf("addr must not be empty")
+	}
+	for key, dir := range map[string]*string{"data_dir": &c.DataDir, "assets_dir": &c.AssetsDir} {
+		if !filepath.IsAbs(*dir) {
+			*dir = filepath.Join(base[key], *dir)
+		}
+		abs, err := filepath.Abs(*dir)
+		if err != nil {
+			return c, err
+		}
+		*dir = abs
+	}
+	return c, nil
+}
+
+// executableDir is the directory holding the running binary, where relative
+// default directories are resolved.
+func executableDir() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", fmt.Errorf("finding the executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	return filepath.Dir(exe), nil
+}
+
+// readFile reads a config file into raw setting values.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	if text := strings.TrimSpace(string(data)); strings.HasPrefix(text, "{") {
+		var raw map[string]any
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		for k, v := range raw {
+			values[k] = jsonValue(v)
+		}
+		return values, nil
+	}
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: want key = value", path, i+1)
+		}
+		v = strings.TrimSpace(v)
+		if uq, err := strconv.Unquote(v); err == nil {
+			v = uq
+		}
+		values[strings.TrimSpace(k)] = v
+	}
+	return values, nil
+}
+
+// jsonValue flattens a JSON value into the string form the setters take.
+func jsonValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []any:
+		parts := []string{}
+		for _, e := range v {
+			parts = append(parts, jsonValue(e))
+		}
+		return strings.Join(parts, ",")
+	case map[string]any:
+		parts := []string{}
+		for k, e := range v {
+			parts = append(parts, k+"="+jsonValue(e))
+		}
+		return strings.Join(parts, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// String lists the effective settings, for logging at startup. The debug
+// token itself is never shown.
+func (c Config) String() string {
+	parts := []string{}
+	if c.File != "" {
+		parts = append(parts, "config="+c.File)
+	}
+	for _, s := range settings {
+		parts = append(parts, s.key+"="+s.show(c))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Server returns an http.Server for handler with the configured address and
+// timeouts.
+func (c Config) Server(handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              c.Addr,
+		Handler:           handler,
+		ReadHeaderTimeout: c.ReadTimeout,
+		ReadTimeout:       c.ReadTimeout,
+		WriteTimeout:      c.WriteTimeout,
+		IdleTimeout:       c.IdleTimeout,
+	}
+}
+
+// Serve runs srv until it fails or the process gets SIGINT or SIGTERM. On a
+// signal it stops accepting connections, waits up to ShutdownTimeout for
+// in-flight requests to finish and returns nil. Shutdown doesn't wait for long-lived streams
+// or hijacked connections; servers end those from srv.RegisterOnShutdown.
+func (c Config) Serve(srv *http.Server) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() { errc <- srv.ListenAndServe() }()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	stop() // a second signal kills the process outright
+	log.Printf("shutting down, waiting up to %s for requests to finish", c.ShutdownTimeout)
+	sctx, cancel := context.WithTimeout(context.Background(), c.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(sctx); err != nil {
+		log.Printf("shutdown: %v", err)
+	}
+	return nil
+}
+
+// AllowsOrigin reports whether a browser page from origin may call the API.
+func (c Config) AllowsOrigin(origin string) bool {
+	return slices.Contains(c.AllowedOrigins, "*") || slices.Contains(c.AllowedOrigins, origin)
+}
+
+// CORS lets the allowed origins call next from a browser and answers
+// preflight requests itself.
+func (c Config) CORS(next http.Handler) http.Handler {
+	anyOrigin := slices.Contains(c.AllowedOrigins, "*")
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		h := w.Header()
+		if origin != "" && c.AllowsOrigin(origin) {
+			if anyOrigin {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+				h.Add("Vary", "Origin")
+			}
+			h.Set("Access-Control-Allow-Methods", "GET, POST, DELETE, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "Content-Type, X-Request-ID, X-Debug-Token, Last-Event-ID")
+			h.Set("Access-Control-Expose-Headers", "X-Request-ID, Location")
+		}
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSharedFeatures(t *testing.T) {
+	defaults := Defaults()
+	defaults.Features = map[string]bool{"preview": true}
+
+	t.Setenv("VOIDSPARK_FEATURES", "ws=false,preview=false")
+	c, err := Load("test", defaults, nil)
+	if err != nil {
+		t.Fatalf("env: %v", err)
+	}
+	if c.Enabled("preview") || len(c.Features) != 1 {
+		t.Errorf("env: features = %v, want only preview=false", c.Features)
+	}
+
+	file := filepath.Join(t.TempDir(), "voidspark.conf")
+	if err := os.WriteFile(file, []byte("features = sse=false\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("VOIDSPARK_FEATURES", "")
+	if _, err := Load("test", defaults, []string{"-config", file}); err != nil {
+		t.Errorf("file: %v", err)
+	}
+
+	if _, err := Load("test", defaults, []string{"-features", "ws=false"}); err == nil {
+		t.Error("-features accepted a feature the server doesn't have")
+	}
+}
+
+func TestRelativeDirs(t *testing.T) {
+	exeDir, err := executableDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	confDir := t.TempDir()
+	file := filepath.Join(confDir, "voidspark.conf")
+	if err := os.WriteFile(file, []byte("assets_dir = shipped\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cwd := t.TempDir()
+	t.Chdir(cwd)
+	defaults := Defaults()
+	defaults.DataDir = filepath.Join("..", "data")
+
+	c, err := Load("test", defaults, []string{"-config", file})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(exeDir, "..", "data"); c.DataDir != want {
+		t.Errorf("default data dir = %s, want %s next to the executable", c.DataDir, want)
+	}
+	if want := filepath.Join(confDir, "shipped"); c.AssetsDir != want {
+		t.Errorf("file assets dir = %s, want %s next to the config file", c.AssetsDir, want)
+	}
+
+	c, err = Load("test", defaults, []string{"-data-dir", "saves"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(cwd, "saves"); c.DataDir != want {
+		t.Errorf("-data-dir saves = %s, want %s", c.DataDir, want)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogEvent is one entry in a world's event stream. Everything that happens in
+// play is emitted as a typed event; Text is rendered from the other fields and
+// is what lands in World.Log, so clients can use whichever suits them.
+type LogEvent struct {
+	Seq    int       `json:"seq"`
+	Type   string    `json:"type"`
+	Actor  string    `json:"actor,omitempty"`
+	Target string    `json:"target,omitempty"`
+	Amount int       `json:"amount,omitempty"`
+	Value  int       `json:"value,omitempty"` // secondary number, e.g. HP left after a hit
+	Room   int       `json:"room"`
+	Detail string    `json:"detail,omitempty"`
+	Time   time.Time `json:"time"`
+	Text   string    `json:"text"`
+}
+
+// eventTemplates renders each event type. Placeholders are {actor},
+// {target}, {amount}, {value}, {room} and {detail}; unknown types render as
+// just their detail.
+var eventTemplates = map[string]string{
+	"world_spawned":      "Spawned world: {detail}",
+	"party_assembled":    "Party assembled: {detail}",
+	"meta_unlock":        "Meta unlock applied: {detail}",
+	"room_entered":       "Entering room {room}: {detail}",
+	"room_passed":        "Passing back through room {room}",
+	"npc_present":        "{actor} is here, looking {detail}.",
+	"combat_skipped":     "No party present — combat skipped",
+	"enemy_appears":      "{actor} appears with {amount} HP",
+	"boss_appears":       "The boss appears with {amount} HP!",
+	"miss":               "{actor} misses",
+	"hit":                "{actor} hits {target} for {amount} ({target} HP {value})",
+	"enemy_defeated":     "Enemy defeated",
+	"boss_defeated":      "The boss is defeated!",
+	"party_down":         "All heroes down",
+	"hero_hit":           "{actor} hits {target} for {amount} (HP {value})",
+	"hero_hurt":          "{actor} bites {target} for {amount} (HP {value})",
+	"struck":             "{target} is struck while fleeing for {amount} (HP {value})",
+	"downed":             "{target} is downed! (bleeds out in {value} rooms)",
+	"revived":            "{target} is revived by {actor} (HP {value})",
+	"bled_out":           "{target} has bled out. {detail}",
+	"loot_found":         "Found treasure: {detail}",
+	"trap_triggered":     "Trap triggers: {target} takes {amount} damage (HP {value})",
+	"trap_empty":         "Trap triggers, but no one is alive to be affected.",
+	"trap_disarmed":      "{actor} disarms the trap",
+	"rested":             "Rested: healed {amount} HP total",
+	"party_healed":       "{actor} tends the party's wounds: healed {amount} HP total",
+	"flee_attempt":       "The party tries to flee {detail} from room {room} ({amount}% chance)",
+	"flee_failed":        "The enemies cut off the escape!",
+	"item_dropped":       "In the scramble the party drops the {detail}",
+	"fell_back":          "The party falls back to room {room}",
+	"dashed_past":        "The party dashes past room {room}",
+	"morale":             "The party is {detail} (morale {amount})",
+	"encounter":          "{detail}",
+	"event_outcome":      "{detail}",
+	"item_gained":        "{actor} hands over a {detail}",
+	"item_traded":        "Traded {target} for a {detail}",
+	"item_stolen":        "{actor} vanishes, and so does your {detail}.",
+	"equipped":           "{actor} equips the {detail}",
+	"unequipped":         "{actor} unequips the {detail}",
+	"talk":               "You to {target}: {detail}",
+	"npc_line":           "{actor}: {detail}",
+	"traps_marked":       "{actor} marks traps in rooms {detail}",
+	"objective_added":    "New objective: {detail}",
+	"objective_complete": "Objective complete: {detail}",
+	"objective_failed":   "Objective failed: {detail}",
+	"achievement":        "Achievement unlocked: {detail}",
+	"shards_earned":      "Earned {amount} void shards ({value} total)",
+	"run_finished":       "You have reached the dungeon's end. Victory! 🎉",
+	"run_lost":           "No one is left standing. Game over.",
+	"final_score":        "Final score: {amount}",
+}
+
+func renderEvent(e LogEvent) string {
+	tmpl, ok := eventTemplates[e.Type]
+	if !ok {
+		tmpl = "{detail}"
+	}
+	return strings.NewReplacer(
+		"{actor}", e.Actor,
+		"{target}", e.Target,
+		"{amount}", strconv.Itoa(e.Amount),
+		"{value}", strconv.Itoa(e.Value),
+		"{room}", strconv.Itoa(e.Room),
+		"{detail}", e.Detail,
+	).Replace(tmpl)
+}
+
+// eventListeners react to every emitted event, e.g. achievements.
+var eventListeners []func(w *World, e LogEvent)
+
+// emit stamps e with a sequence number, time and (if unset) the room the
+// party is in, renders its text onto the log and hands it to listeners.
+func emit(w *World, e LogEvent) {
+	e.Seq = len(w.Events) + 1
+	if e.Room == 0 {
+		e.Room = w.here
+	}
+	e.Time = time.Now()
+	e.Text = renderEvent(e)
+	w.Events = append(w.Events, e)
+	w.Log = append(w.Log, e.Text)
+	if w.RunStats == nil {
+		w.RunStats = map[string]int{}
+	}
+	w.RunStats[e.Type]++
+	for _, l := range eventListeners {
+		l(w, e)
+	}
+}
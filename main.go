@@ -1,38 +1,82 @@
 package main
 
 import (
+	crand "crypto/rand"
+	"encoding/binary"
 	"encoding/json"
 	"fmt"
 	"log"
 	"math/rand"
 	"net/http"
 	"os"
+	"path"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+	"github.com/NarlaRohan050/Voidspark/internal/config"
 )
 
 // Void Spark — Prompt → World engine (GTA Jam MVP)
-// Worlds saved to disk, served via /worlds/, visualized via /web/preview
+// Worlds saved to disk, served via /worlds/{id}, visualized via /web/preview
 
 type World struct {
-	ID        string   `json:"id"`
-	Dimension string   `json:"dimension"`
-	Theme     string   `json:"theme"`
-	Aesthetic string   `json:"aesthetic"`
-	Rooms     []Room   `json:"rooms"`
-	Seed      int64    `json:"seed"`
-	Current   int      `json:"current"`
-	GameState string   `json:"game_state"`
-	Party     []Hero   `json:"party"`
-	Log       []string `json:"log"`
+	ID         string   `json:"id"`
+	Prompt     string   `json:"prompt"`
+	Dimension  string   `json:"dimension"`
+	Theme      string   `json:"theme"`
+	Aesthetic  string   `json:"aesthetic"`
+	Difficulty string   `json:"difficulty"`
+	Rooms      []Room   `json:"rooms"`
+	Seed       int64    `json:"seed,omitempty"` // hidden from players, it gives the map away
+	Current    int      `json:"current"`
+	GameState  string   `json:"game_state"`
+	Permadeath bool     `json:"permadeath"`
+	Party      []Hero   `json:"party"`
+	Inventory  []string `json:"inventory"`
+	Morale     int      `json:"morale"`
+	Fatigue    int      `json:"fatigue"`
+	// RoomsSinceRest counts rooms since the last rest, for morale drain.
+	RoomsSinceRest int `json:"rooms_since_rest"`
+	// Event is the encounter waiting for a choice, if any.
+	Event      *Event      `json:"event,omitempty"`
+	EventDeck  []string    `json:"event_deck,omitempty"`
+	Objectives []Objective `json:"objectives"`
+	Kills      int         `json:"kills"`
+	Moves      int         `json:"moves"`
+	NPCs       []NPC       `json:"npcs"`
+	Player     string      `json:"player"`
+	Profile    string      `json:"profile,omitempty"` // owning profile ID
+	Meta       bool        `json:"meta"`
+	LootFound  int         `json:"loot_found"`
+	Score      int         `json:"score"`
+	// Daily is the challenge date (YYYY-MM-DD) for daily runs.
+	Daily string `json:"daily,omitempty"`
+	// CreatedAt is when the world was generated; zero for worlds saved
+	// before it was recorded.
+	CreatedAt time.Time `json:"created_at"`
+	// RunStats counts the events of each type emitted during this run.
+	RunStats map[string]int `json:"run_stats"`
+	Events   []LogEvent     `json:"events"`
+	// Log holds the rendered text of Events, one line per event.
+	Log []string `json:"log"`
+
+	here int // index of the room the party is in, stamped on events
 }
 
 type Room struct {
 	Index int    `json:"index"`
 	Type  string `json:"type"` // combat/loot/trap/rest
 	Desc  string `json:"desc"`
+	State string `json:"state,omitempty"` // ""/cleared/unresolved
+	Boss  bool   `json:"boss,omitempty"`
+	// TrapKnown marks a trap the party was warned about; it does half damage.
+	TrapKnown  bool `json:"trap_known,omitempty"`
+	Discovered bool `json:"discovered"`
+	Visited    bool `json:"visited"`
 }
 
 type Hero struct {
@@ -41,41 +85,163 @@ type Hero struct {
 	HP    int            `json:"hp"`
 	MaxHP int            `json:"max_hp"`
 	Stats map[string]int `json:"stats"`
+	// Abilities are copied from the hero's class; see classes.go.
+	Abilities []string `json:"abilities"`
+	// Equipment maps slot (weapon/armor/accessory) to an item in gearCatalog.
+	Equipment map[string]string `json:"equipment"`
+	// Status is "" for a hero on their feet, "downed" or "dead".
+	Status   string `json:"status,omitempty"`
+	BleedOut int    `json:"bleed_out,omitempty"`
 }
 
 var (
 	store   = map[string]*World{}
 	storeMu sync.Mutex
+
+	// worldMu serialises everything that reads or changes a stored world:
+	// actions, views, stream snapshots and saves. Take it after storeMu and
+	// before feedsMu, profilesMu or dirtyMu.
+	worldMu sync.Mutex
 )
 
+// cfg is the server's configuration; see internal/config. Features: ws (the
+// /ws WebSocket), sse (/worlds/{id}/events), ui (the page on / and /web/) and
+// openapi (/openapi.json).
+var cfg = defaultConfig()
+
+func defaultConfig() config.Config {
+	c := config.Defaults()
+	c.Features = map[string]bool{"ws": true, "sse": true, "ui": true, "openapi": true}
+	return c
+}
+
 func main() {
 	rand.Seed(time.Now().UnixNano())
 
+	var err error
+	if cfg, err = config.Load("voidspark", defaultConfig(), os.Args[1:]); err != nil {
+		log.Fatalf("config: %v", err)
+	}
+	log.Printf("config: %s", cfg)
+
 	// Ensure worlds/ folder exists
-	if _, err := os.Stat("worlds"); os.IsNotExist(err) {
-		if err := os.Mkdir("worlds", 0755); err != nil {
-			log.Fatalf("failed to create worlds folder: %v", err)
-		}
+	if err := os.MkdirAll(dataPath("worlds"), 0755); err != nil {
+		log.Fatalf("failed to create worlds folder: %v", err)
 	}
 
-	// Core API
-	http.HandleFunc("/", uiHandler)
-	http.HandleFunc("/generate", generateHandler)
-	http.HandleFunc("/party", partyHandler)
-	http.HandleFunc("/explore", exploreHandler)
-	http.HandleFunc("/state", stateHandler)
+	loadLeaderboard()
+	loadDailyAttempts()
+	loadProfiles()
+	loadClasses()
+
+	log.Printf("Void Spark listening on %s", cfg.Addr)
+	log.Printf("Preview: /web/preview/world_preview.html")
+	srv := cfg.Server(apierr.WithRequestID(cfg.CORS(routes())))
+	srv.RegisterOnShutdown(endStreams)
+	if err := cfg.Serve(srv); err != nil {
+		log.Fatal(err)
+	}
+	if !waitSessions(cfg.ShutdownTimeout) {
+		log.Printf("websocket sessions still running after %s, saving anyway", cfg.ShutdownTimeout)
+	}
+	flushWorlds()
+}
+
+// dataPath joins elem onto the data directory.
+func dataPath(elem ...string) string {
+	return filepath.Join(append([]string{cfg.DataDir}, elem...)...)
+}
+
+// assetPath joins elem onto the assets directory.
+func assetPath(elem ...string) string {
+	return filepath.Join(append([]string{cfg.AssetsDir}, elem...)...)
+}
+
+func routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	feature := func(name string, h http.HandlerFunc) http.HandlerFunc {
+		if cfg.Enabled(name) {
+			return h
+		}
+		return featureDisabled
+	}
+
+	// Worlds as resources
+	mux.HandleFunc("GET /worlds", listWorldsHandler)
+	mux.HandleFunc("POST /worlds", createWorldHandler)
+	mux.HandleFunc("GET /worlds/{id}", getWorldHandler)
+	mux.HandleFunc("DELETE /worlds/{id}", deleteWorldHandler)
+	mux.HandleFunc("POST /worlds/{id}/party", worldPartyHandler)
+	mux.HandleFunc("POST /worlds/{id}/actions", worldActionHandler)
+	mux.HandleFunc("GET /worlds/{id}/events", feature("sse", worldEventsHandler))
+	mux.HandleFunc("/ws", feature("ws", wsHandler))
+	mux.HandleFunc("GET /openapi.json", feature("openapi", openAPIHandler))
+
+	// Legacy verb routes, kept as aliases while clients move over
+	mux.HandleFunc("/generate", generateHandler)
+	mux.HandleFunc("/party", partyHandler)
+	mux.HandleFunc("/explore", exploreHandler)
+	mux.HandleFunc("/state", stateHandler)
+	mux.HandleFunc("/talk", talkHandler)
+	mux.HandleFunc("/equip", equipHandler)
+
+	// Everything else
+	mux.HandleFunc("GET /{$}", feature("ui", uiHandler))
+	mux.HandleFunc("/leaderboard", leaderboardHandler)
+	mux.HandleFunc("/daily", dailyHandler)
+	mux.HandleFunc("/daily/leaderboard", dailyLeaderboardHandler)
+	mux.HandleFunc("/achievements", achievementsHandler)
+	mux.HandleFunc("/profiles", profilesHandler)
+	mux.HandleFunc("/profiles/favorites", favoriteSeedHandler)
+	mux.HandleFunc("/meta/shop", metaShopHandler)
+	mux.HandleFunc("/classes", classesHandler)
 
 	// World persistence & preview support
-	http.Handle("/worlds/", http.StripPrefix("/worlds/", http.FileServer(http.Dir("worlds"))))
-	http.HandleFunc("/api/latest-world", latestWorldHandler)
+	mux.HandleFunc("/api/latest-world", latestWorldHandler)
 
 	// Static assets (preview HTML)
-	http.Handle("/web/", http.StripPrefix("/web/", http.FileServer(http.Dir("web"))))
+	mux.HandleFunc("/web/", feature("ui", webHandler))
 
-	port := "8080"
-	log.Printf("Void Spark running at http://localhost:%s", port)
-	log.Printf("Preview: http://localhost:%s/web/preview/world_preview.html", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	// Anything no route above takes
+	mux.HandleFunc("/", unrouted(mux))
+	return mux
+}
+
+// unrouted answers requests that match no other route with the error envelope
+// rather than ServeMux's plain-text 404 and 405: 405 when the path is served
+// under other methods, 404 otherwise.
+func unrouted(mux *http.ServeMux) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var allow []string
+		for _, method := range []string{"GET", "POST", "DELETE"} {
+			probe := *r
+			probe.Method = method
+			if _, pattern := mux.Handler(&probe); pattern != "/" && pattern != "" {
+				allow = append(allow, method)
+			}
+		}
+		if len(allow) > 0 {
+			w.Header().Set("Allow", strings.Join(allow, ", "))
+			apierr.Write(w, r, apierr.New(apierr.MethodNotAllowed, "%s is not allowed on %s", r.Method, r.URL.Path))
+			return
+		}
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "no route for %s", r.URL.Path))
+	}
+}
+
+// webHandler serves the static assets under /web/, with the error envelope
+// for files that aren't there.
+func webHandler(w http.ResponseWriter, r *http.Request) {
+	name := path.Clean("/" + strings.TrimPrefix(r.URL.Path, "/web/"))
+	if _, err := os.Stat(assetPath("web", filepath.FromSlash(name))); err != nil {
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "no asset at %s", r.URL.Path))
+		return
+	}
+	http.StripPrefix("/web/", http.FileServer(http.Dir(assetPath("web")))).ServeHTTP(w, r)
+}
+
+func featureDisabled(w http.ResponseWriter, r *http.Request) {
+	apierr.Write(w, r, apierr.New(apierr.NotFound, "%s is disabled on this server", r.URL.Path))
 }
 
 func uiHandler(w http.ResponseWriter, r *http.Request) {
@@ -86,31 +252,85 @@ func uiHandler(w http.ResponseWriter, r *http.Request) {
 <h2>Void Spark — Prompt → World (MVP)</h2>
 <label>Prompt (world):</label><br>
 <textarea id="prompt" rows="3" cols="64">a dark stone dungeon with countless treasure, candlelight, and traps</textarea><br>
+<label>Player:</label>
+<input id="player" value="anonymous">
+<label>Difficulty:</label>
+<select id="difficulty"><option>easy</option><option selected>normal</option><option>hard</option></select><br>
 <button onclick="generate()">Generate World</button>
+<input id="roles" placeholder="roles, e.g. tank,mage,rogue" size="28">
 <button onclick="createParty()">Create Party</button>
 <button onclick="explore()">Go Forward (Explore)</button>
+<button onclick="flee('back')">Flee Back</button>
+<button onclick="flee('forward')">Flee Past</button>
+<input id="hero" placeholder="hero to revive" size="14">
+<button onclick="revive()">Revive</button>
 <button onclick="showState()">Show State</button>
+<div id="event"></div>
 <p><a href="/web/preview/world_preview.html" target="_blank">🌀 Open Live Preview</a></p>
 <pre id="out" style="white-space:pre-wrap;border:1px solid #ddd;padding:10px;margin-top:12px;height:420px;overflow:auto"></pre>
 <script>
 let sessionId = ''
 async function generate(){
   const prompt = document.getElementById('prompt').value
-  const res = await fetch('/generate',{method:'POST',headers:{'Content-Type':'application/json'},body:JSON.stringify({prompt})})
+  const difficulty = document.getElementById('difficulty').value
+  const player = document.getElementById('player').value
+  const res = await fetch('/generate',{method:'POST',headers:{'Content-Type':'application/json'},body:JSON.stringify({prompt, difficulty, player})})
   const js = await res.json()
   sessionId = js.id
   document.getElementById('out').innerText = JSON.stringify(js, null, 2)
 }
 async function createParty(){
   if(!sessionId){alert('Generate a world first');return}
-  const res = await fetch('/party',{method:'POST',headers:{'Content-Type':'application/json'},body:JSON.stringify({id:sessionId})})
+  const roles = document.getElementById('roles').value.split(',').map(s => s.trim()).filter(Boolean)
+  const res = await fetch('/party',{method:'POST',headers:{'Content-Type':'application/json'},body:JSON.stringify({id:sessionId, roles})})
+  if(!res.ok){document.getElementById('out').innerText = await res.text();return}
   const js = await res.json()
   document.getElementById('out').innerText = JSON.stringify(js, null, 2)
 }
 async function explore(){
   if(!sessionId){alert('Generate a world first');return}
   const res = await fetch('/explore',{method:'POST',headers:{'Content-Type':'application/json'},body:JSON.stringify({id:sessionId})})
+  if(!res.ok){document.getElementById('out').innerText = await res.text();return}
+  const js = await res.json()
+  showEvent(js)
+  document.getElementById('out').innerText = JSON.stringify(js, null, 2)
+}
+function showEvent(js){
+  const el = document.getElementById('event')
+  el.innerHTML = ''
+  if(!js.event) return
+  const p = document.createElement('p')
+  p.innerText = js.event.text
+  el.appendChild(p)
+  js.event.choices.forEach(c => {
+    const b = document.createElement('button')
+    b.innerText = c.label
+    b.onclick = () => choose(c.id)
+    el.appendChild(b)
+  })
+}
+async function choose(choice){
+  const res = await fetch('/explore',{method:'POST',headers:{'Content-Type':'application/json'},body:JSON.stringify({id:sessionId, action:'choose', choice})})
+  if(!res.ok){document.getElementById('out').innerText = await res.text();return}
   const js = await res.json()
+  showEvent(js)
+  document.getElementById('out').innerText = JSON.stringify(js, null, 2)
+}
+async function flee(direction){
+  if(!sessionId){alert('Generate a world first');return}
+  const res = await fetch('/explore',{method:'POST',headers:{'Content-Type':'application/json'},body:JSON.stringify({id:sessionId, action:'flee', direction})})
+  if(!res.ok){document.getElementById('out').innerText = await res.text();return}
+  const js = await res.json()
+  showEvent(js)
+  document.getElementById('out').innerText = JSON.stringify(js, null, 2)
+}
+async function revive(){
+  if(!sessionId){alert('Generate a world first');return}
+  const hero = document.getElementById('hero').value.trim()
+  const res = await fetch('/explore',{method:'POST',headers:{'Content-Type':'application/json'},body:JSON.stringify({id:sessionId, action:'revive', hero})})
+  if(!res.ok){document.getElementById('out').innerText = await res.text();return}
+  const js = await res.json()
+  showEvent(js)
   document.getElementById('out').innerText = JSON.stringify(js, null, 2)
 }
 async function showState(){
@@ -126,163 +346,310 @@ async function showState(){
 	_, _ = w.Write([]byte(html))
 }
 
+// worldOptions is the body of POST /generate.
+type worldOptions struct {
+	Prompt     string `json:"prompt"`
+	Difficulty string `json:"difficulty"`
+	Permadeath bool   `json:"permadeath"`
+	Player     string `json:"player"`
+	Seed       int64  `json:"seed"` // replay a known seed; 0 picks a fresh one
+	Daily      string `json:"-"`    // set by /daily only
+	Meta       bool   `json:"meta"` // earn and spend meta-progression shards
+}
+
 func generateHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
-		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		apierr.Write(w, r, apierr.New(apierr.MethodNotAllowed, "POST only"))
 		return
 	}
-	var req struct{ Prompt string `json:"prompt"` }
+	var req worldOptions
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		apierr.Write(w, r, apierr.BadBody(err))
 		return
 	}
-	theme, aesthetic, dim := parsePrompt(req.Prompt)
+	wld := createWorld(req)
+	writeView(w, wld)
+}
+
+// createWorld builds a world from opts, stores it and saves it to disk.
+func createWorld(opts worldOptions) *World {
+	theme, aesthetic, dim := parsePrompt(opts.Prompt)
 	seed := time.Now().UnixNano()
-	wld := buildWorld(theme, aesthetic, dim, seed)
+	if opts.Seed != 0 {
+		seed = opts.Seed
+	}
+	wld := buildWorld(opts.Prompt, theme, aesthetic, dim, normalizeDifficulty(opts.Difficulty), seed)
+	// Several runs can share a seed, and the seed gives the map away, so the
+	// ID can't be the seed itself.
+	wld.ID = newWorldID()
+	wld.Permadeath = opts.Permadeath
+	wld.Daily = opts.Daily
+	wld.Meta = opts.Meta
+	wld.CreatedAt = time.Now()
+	wld.Player = opts.Player
+	if wld.Player == "" {
+		wld.Player = "anonymous"
+	}
+	linkProfile(wld)
 
 	storeMu.Lock()
 	store[wld.ID] = wld
 	storeMu.Unlock()
 
-	// Save to disk
-	data, _ := json.MarshalIndent(wld, "", "  ")
-	filename := fmt.Sprintf("worlds/world_%s.json", wld.ID)
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := saveWorld(wld); err != nil {
 		log.Printf("failed to save world json: %v", err)
 	}
+	return wld
+}
 
-	writeJSON(w, wld)
+// newWorldID returns a random world ID that says nothing about the seed.
+func newWorldID() string {
+	var b [8]byte
+	_, _ = crand.Read(b[:])
+	return strconv.FormatUint(binary.BigEndian.Uint64(b[:])>>1, 10)
 }
 
+// latestWorldHandler serves GET /api/latest-world: the id of the newest world,
+// to be fetched as /worlds/{id}.
 func latestWorldHandler(w http.ResponseWriter, r *http.Request) {
-	files, err := os.ReadDir("worlds")
-	if err != nil || len(files) == 0 {
-		http.Error(w, "no worlds found", http.StatusNotFound)
-		return
-	}
-
-	var latestFile os.DirEntry
-	var latestTime time.Time
-
-	for _, file := range files {
-		if strings.HasSuffix(file.Name(), ".json") {
-			info, err := file.Info()
-			if err != nil {
-				continue
-			}
-			if info.ModTime().After(latestTime) {
-				latestTime = info.ModTime()
-				latestFile = file
-			}
+	storeMu.Lock()
+	var latest *World
+	for _, wld := range store {
+		if latest == nil || wld.CreatedAt.After(latest.CreatedAt) {
+			latest = wld
 		}
 	}
-
-	if latestFile == nil {
-		http.Error(w, "no valid world files found", http.StatusNotFound)
+	storeMu.Unlock()
+	if latest == nil {
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "no worlds found"))
 		return
 	}
-
-	writeJSON(w, map[string]string{"latest": latestFile.Name()})
+	writeJSON(w, map[string]string{"latest": latest.ID})
 }
 
 func partyHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
-		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		apierr.Write(w, r, apierr.New(apierr.MethodNotAllowed, "POST only"))
 		return
 	}
-	var req struct{ ID string `json:"id"` }
+	var req struct {
+		ID    string   `json:"id"`
+		Roles []string `json:"roles"` // optional; see /classes
+	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		apierr.Write(w, r, apierr.BadBody(err))
 		return
 	}
 	storeMu.Lock()
 	wld, ok := store[req.ID]
 	storeMu.Unlock()
 	if !ok {
-		http.Error(w, "session not found", http.StatusNotFound)
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "session not found"))
 		return
 	}
-	if len(wld.Party) == 0 {
-		wld.Party = generateParty()
-		wld.Log = append(wld.Log, "Party assembled: "+rolesList(wld.Party))
+	if err := assembleParty(wld, req.Roles); err != nil {
+		writeError(w, r, err)
+		return
 	}
-	writeJSON(w, wld)
+	writeView(w, wld)
+}
+
+// assembleParty gives a world its party. Asking again once there is one is a
+// no-op.
+func assembleParty(wld *World, roles []string) error {
+	worldMu.Lock()
+	defer worldMu.Unlock()
+	if len(wld.Party) > 0 {
+		return nil
+	}
+	party, err := generateParty(wld.Theme, roles)
+	if err != nil {
+		return apierr.Invalid("roles", "%s", err.Error())
+	}
+	wld.Party = party
+	applyMeta(wld)
+	emit(wld, LogEvent{Type: "party_assembled", Detail: rolesList(wld.Party)})
+	publishState(wld)
+	return nil
+}
+
+// Move is one step of play: explore (default), flee, choose or revive.
+type Move struct {
+	Action    string `json:"action"`         // explore (default), flee, choose or revive
+	Direction string `json:"direction"`      // for flee: back or forward
+	Choice    string `json:"choice"`         // for choose: id of an event choice
+	Hero      string `json:"hero,omitempty"` // for revive: the downed or dead hero
 }
 
 func exploreHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
-		http.Error(w, "POST only", http.StatusMethodNotAllowed)
+		apierr.Write(w, r, apierr.New(apierr.MethodNotAllowed, "POST only"))
 		return
 	}
-	var req struct{ ID string `json:"id"` }
+	var req struct {
+		ID string `json:"id"`
+		Move
+	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+		apierr.Write(w, r, apierr.BadBody(err))
 		return
 	}
 	storeMu.Lock()
 	wld, ok := store[req.ID]
 	storeMu.Unlock()
 	if !ok {
-		http.Error(w, "session not found", http.StatusNotFound)
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "session not found"))
 		return
 	}
-	if wld.GameState == "game_over" || wld.GameState == "finished" {
-		writeJSON(w, wld)
+	if err := play(wld, req.Move); err != nil {
+		writeError(w, r, err)
 		return
 	}
+	writeView(w, wld)
+}
+
+// play applies one move. Moves on a finished run are ignored. A move that is
+// refused changes nothing: it isn't counted and followers aren't told.
+func play(wld *World, m Move) (err error) {
+	worldMu.Lock()
+	defer worldMu.Unlock()
+	if wld.GameState == "game_over" || wld.GameState == "finished" {
+		return nil
+	}
+	switch m.Action {
+	case "", "explore", "flee", "choose", "revive":
+	default:
+		return apierr.Invalid("action", "unknown action: %s", m.Action)
+	}
+	defer func() {
+		if err == nil {
+			publishState(wld)
+		}
+	}()
+	if m.Action == "choose" {
+		if err := resolveEvent(wld, m.Choice); err != nil {
+			return err
+		}
+		checkGameOver(wld)
+		settle(wld)
+		return nil
+	}
+	if wld.Event != nil {
+		return apierr.New(apierr.EventPending, "an event is waiting for a choice: %s", choiceIDs(wld.Event))
+	}
+	if m.Action == "revive" {
+		// Reviving takes the move, but the party stays where it is.
+		if err := reviveHero(wld, m.Hero); err != nil {
+			return err
+		}
+		wld.Moves++
+		settle(wld)
+		return nil
+	}
+	if m.Action == "flee" {
+		if err := canFlee(wld, m.Direction); err != nil {
+			return err
+		}
+	}
 	if wld.Current >= len(wld.Rooms) {
-		wld.Log = append(wld.Log, "You have reached the dungeon's end. Victory! 🎉")
 		wld.GameState = "finished"
-		writeJSON(w, wld)
-		return
+		emit(wld, LogEvent{Type: "run_finished"})
+		settle(wld)
+		return nil
 	}
 	room := wld.Rooms[wld.Current]
-	wld.Log = append(wld.Log, fmt.Sprintf("Entering room %d: %s (%s)", room.Index, room.Desc, room.Type))
+	before := partyStatuses(wld)
+	if m.Action == "flee" {
+		flee(wld, m.Direction)
+	} else {
+		enterRoom(wld)
+	}
+	wld.Moves++
+	tickDowned(wld, room)
+	updateMorale(wld, room, m.Action, before)
+	over := checkGameOver(wld)
+	settle(wld)
+	if !over {
+		maybeDrawEvent(wld)
+	}
+	return nil
+}
+
+// runOver refuses anything but play on a run that has ended.
+func runOver(w *World) error {
+	if w.GameState == "game_over" || w.GameState == "finished" {
+		return apierr.New(apierr.RunOver, "the run is over (%s)", w.GameState)
+	}
+	return nil
+}
+
+func checkGameOver(w *World) bool {
+	if standing(w) > 0 {
+		return false
+	}
+	w.GameState = "game_over"
+	emit(w, LogEvent{Type: "run_lost"})
+	return true
+}
+
+// enterRoom resolves the room at w.Current and moves the party past it. Rooms
+// already cleared on an earlier pass are just walked through.
+func enterRoom(w *World) {
+	room := &w.Rooms[w.Current]
+	w.here = room.Index
+	if room.State == "cleared" {
+		emit(w, LogEvent{Type: "room_passed"})
+		w.Current++
+		return
+	}
+	discover(w, w.Current)
+	emit(w, LogEvent{Type: "room_entered", Detail: fmt.Sprintf("%s (%s)", room.Desc, room.Type)})
 	switch room.Type {
 	case "combat":
-		clog := resolveCombat(wld, wld.Seed+int64(wld.Current))
-		wld.Log = append(wld.Log, clog...)
+		resolveCombat(w, w.Seed+int64(w.Current))
 	case "loot":
-		loot := randomLoot(wld.Seed + int64(wld.Current))
-		wld.Log = append(wld.Log, "Found treasure: "+loot)
+		loot := randomLoot(w.Seed + int64(w.Current))
+		w.Inventory = append(w.Inventory, loot)
+		w.LootFound++
+		emit(w, LogEvent{Type: "loot_found", Detail: loot})
 	case "trap":
-		effect := triggerTrap(wld, wld.Seed+int64(wld.Current))
-		wld.Log = append(wld.Log, effect)
+		triggerTrap(w, w.Seed+int64(w.Current))
 	case "rest":
-		heal := restParty(wld)
-		wld.Log = append(wld.Log, fmt.Sprintf("Rested: healed %d HP total", heal))
-	}
-	wld.Current++
-	alive := 0
-	for _, h := range wld.Party {
-		if h.HP > 0 {
-			alive++
-		}
+		restParty(w)
 	}
-	if alive == 0 {
-		wld.Log = append(wld.Log, "All party members have fallen. Game over.")
-		wld.GameState = "game_over"
-	}
-	storeMu.Lock()
-	store[req.ID] = wld
-	storeMu.Unlock()
-	writeJSON(w, wld)
+	room.State = "cleared"
+	w.Current++
+	npcsIn(w, room.Index)
 }
 
 func stateHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("id")
 	if id == "" {
-		http.Error(w, "id required", http.StatusBadRequest)
+		apierr.Write(w, r, apierr.Invalid("id", "id required"))
 		return
 	}
+	showWorld(w, r, id)
+}
+
+// showWorld writes the player view of a world, or the full world for
+// ?view=debug with a valid debug token.
+func showWorld(w http.ResponseWriter, r *http.Request, id string) {
 	storeMu.Lock()
 	wld, ok := store[id]
 	storeMu.Unlock()
 	if !ok {
-		http.Error(w, "not found", http.StatusNotFound)
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "not found"))
+		return
+	}
+	if r.URL.Query().Get("view") == "debug" {
+		if !isDebugRequest(r) {
+			apierr.Write(w, r, apierr.New(apierr.Forbidden, "debug view requires a valid X-Debug-Token"))
+			return
+		}
+		writeJSON(w, lockedJSON(func() interface{} { return wld }))
 		return
 	}
-	writeJSON(w, wld)
+	writeView(w, wld)
 }
 
 func writeJSON(w http.ResponseWriter, v interface{}) {
@@ -292,6 +659,23 @@ func writeJSON(w http.ResponseWriter, v interface{}) {
 	_ = enc.Encode(v)
 }
 
+// writeView writes the player view of wld.
+func writeView(w http.ResponseWriter, wld *World) {
+	writeJSON(w, lockedJSON(func() interface{} { return playerView(wld) }))
+}
+
+// lockedJSON marshals what v returns while holding worldMu, so an action on
+// another connection can't change the world halfway through.
+func lockedJSON(v func() interface{}) json.RawMessage {
+	worldMu.Lock()
+	defer worldMu.Unlock()
+	data, err := json.Marshal(v())
+	if err != nil {
+		return json.RawMessage("null")
+	}
+	return data
+}
+
 func parsePrompt(prompt string) (theme, aesthetic, dim string) {
 	p := strings.ToLower(prompt)
 	dim = "2D"
@@ -321,10 +705,11 @@ func parsePrompt(prompt string) (theme, aesthetic, dim string) {
 	return
 }
 
-func buildWorld(theme, aesthetic, dim string, seed int64) *World {
+func buildWorld(prompt, theme, aesthetic, dim, difficulty string, seed int64) *World {
 	r := rand.New(rand.NewSource(seed))
 	roomCount := r.Intn(5) + 8 // 8–12 rooms
 	var rooms []Room
+	var types []string
 
 	// Flavor pools
 	enemies := []string{"goblin", "skeleton", "slime", "bandit", "warg", "orc", "shadow knight", "rat swarm"}
@@ -364,54 +749,81 @@ func buildWorld(theme, aesthetic, dim string, seed int64) *World {
 	}
 
 	for i := 1; i <= roomCount; i++ {
-		var t, desc string
-		switch r.Intn(4) {
-		case 0: // loot
-			t = "loot"
+		var desc string
+		t := pickRoomType(r, i, roomCount, types)
+		types = append(types, t)
+		switch t {
+		case "loot":
 			treasure := treasures[r.Intn(len(treasures))]
 			flavor := treasureFlavors[r.Intn(len(treasureFlavors))]
 			desc = fmt.Sprintf("A %s, %s.", treasure, flavor)
-		case 1: // combat
-			t = "combat"
+		case "combat":
 			enemy := enemies[r.Intn(len(enemies))]
 			action := enemyActions[r.Intn(len(enemyActions))]
 			desc = fmt.Sprintf("A %s %s.", enemy, action)
-		case 2: // trap
-			t = "trap"
+		case "trap":
 			trap := traps[r.Intn(len(traps))]
 			flavor := trapFlavors[r.Intn(len(trapFlavors))]
 			desc = fmt.Sprintf("A %s, %s.", trap, flavor)
-		case 3: // rest
-			t = "rest"
+		case "rest":
 			spot := restSpots[r.Intn(len(restSpots))]
 			flavor := restFlavors[r.Intn(len(restFlavors))]
 			desc = fmt.Sprintf("A %s, %s.", spot, flavor)
 		}
 		rooms = append(rooms, Room{Index: i, Type: t, Desc: desc})
 	}
+	rooms[0].Discovered = true
+	for i := len(rooms) - 1; i >= 0; i-- {
+		if rooms[i].Type == "combat" {
+			rooms[i].Boss = true
+			break
+		}
+	}
 
-	return &World{
+	w := &World{
 		ID:         strconv.FormatInt(seed, 10),
+		Prompt:     prompt,
 		Dimension:  dim,
 		Theme:      theme,
 		Aesthetic:  aesthetic,
+		Difficulty: difficulty,
 		Rooms:      rooms,
 		Seed:       seed,
 		Current:    0,
 		GameState:  "exploring",
-		Log:        []string{fmt.Sprintf("Spawned world: %s (%s) seed=%d", theme, aesthetic, seed)},
+		Morale:     startMorale,
+		EventDeck:  shuffledDeck(r),
+		Objectives: buildObjectives(r, prompt, theme, rooms),
+		NPCs:       placeNPCs(r, rooms),
 	}
+	emit(w, LogEvent{Type: "world_spawned", Detail: fmt.Sprintf("%s (%s, %s)", theme, aesthetic, difficulty)})
+	return w
 }
 
-func generateParty() []Hero {
-	now := time.Now().UnixNano()
-	base := int(now % 1000)
-	return []Hero{
-		{Name: fmt.Sprintf("Tank-%d", base+1), Role: "tank", MaxHP: 120, HP: 120, Stats: map[string]int{"str": 8, "def": 8}},
-		{Name: fmt.Sprintf("Attacker-%d", base+2), Role: "attacker", MaxHP: 90, HP: 90, Stats: map[string]int{"str": 10, "def": 4}},
-		{Name: fmt.Sprintf("Healer-%d", base+3), Role: "healer", MaxHP: 80, HP: 80, Stats: map[string]int{"int": 9, "def": 3}},
-		{Name: fmt.Sprintf("Support-%d", base+4), Role: "support", MaxHP: 85, HP: 85, Stats: map[string]int{"dex": 7, "def": 4}},
+const maxPartySize = 5
+
+// generateParty builds a party from the given roles, defaulting to the
+// classic tank/attacker/healer/support line-up.
+func generateParty(theme string, roles []string) ([]Hero, error) {
+	if len(roles) == 0 {
+		roles = defaultRoster
+	}
+	if len(roles) > maxPartySize {
+		return nil, fmt.Errorf("a party has at most %d heroes", maxPartySize)
 	}
+	base := partyBase()
+	party := []Hero{}
+	for i, role := range roles {
+		c, ok := classFor(role)
+		if !ok {
+			return nil, fmt.Errorf("unknown role: %s", role)
+		}
+		if !c.availableIn(theme) {
+			return nil, fmt.Errorf("%s is not available in %s worlds", role, theme)
+		}
+		party = append(party, heroFromClass(c, base+i+1))
+	}
+	return party, nil
 }
 
 func rolesList(hs []Hero) string {
@@ -422,30 +834,64 @@ func rolesList(hs []Hero) string {
 	return strings.Join(roles, ", ")
 }
 
-func resolveCombat(w *World, seed int64) []string {
-	logs := []string{}
+// resolveCombat fights the encounter in the current room, which is the boss
+// fight if the room holds one.
+func resolveCombat(w *World, seed int64) {
+	boss := w.Current < len(w.Rooms) && w.Rooms[w.Current].Boss
+	fight(w, seed, boss)
+}
+
+func fight(w *World, seed int64, boss bool) {
 	if len(w.Party) == 0 {
-		logs = append(logs, "No party present — combat skipped")
-		return logs
+		emit(w, LogEvent{Type: "combat_skipped"})
+		return
 	}
 	r := rand.New(rand.NewSource(seed))
+	scale := dangerScale(w, w.Current+1)
+	miss, mult := combatModifiers(w)
 	enemies := 1 + r.Intn(2)
+	if boss {
+		enemies = 1
+	}
 	for e := 0; e < enemies; e++ {
-		enemyHP := 30 + r.Intn(30)
-		logs = append(logs, fmt.Sprintf("Enemy %d appears with %d HP", e+1, enemyHP))
+		enemyHP := scaled(30+r.Intn(30), scale)
+		enemy := fmt.Sprintf("Enemy %d", e+1)
+		if boss {
+			enemyHP *= 2
+			enemy = "Boss"
+			emit(w, LogEvent{Type: "boss_appears", Actor: enemy, Amount: enemyHP})
+		} else {
+			emit(w, LogEvent{Type: "enemy_appears", Actor: enemy, Amount: enemyHP})
+		}
 		for enemyHP > 0 {
 			for i := range w.Party {
 				if w.Party[i].HP <= 0 {
 					continue
 				}
-				damage := 5 + r.Intn(8)
-				if w.Party[i].Role == "attacker" {
+				if r.Intn(100) < miss {
+					emit(w, LogEvent{Type: "miss", Actor: w.Party[i].Name, Target: enemy})
+					continue
+				}
+				damage := 5 + r.Intn(8) + stat(w.Party[i], "str")/3
+				if hasAbility(w.Party[i], "power_strike") {
 					damage += 4
 				}
+				if hasAbility(w.Party[i], "arcane_bolt") {
+					damage += stat(w.Party[i], "int") / 2
+				}
+				if hasAbility(w.Party[i], "crit") && r.Intn(5) == 0 {
+					damage *= 2
+				}
+				damage = max(int(float64(damage)*mult), 1)
 				enemyHP -= damage
-				logs = append(logs, fmt.Sprintf("%s hits enemy for %d (enemy HP %d)", w.Party[i].Name, damage, max(enemyHP, 0)))
+				emit(w, LogEvent{Type: "hit", Actor: w.Party[i].Name, Target: "enemy", Amount: damage, Value: max(enemyHP, 0)})
 				if enemyHP <= 0 {
-					logs = append(logs, "Enemy defeated")
+					w.Kills++
+					if boss {
+						emit(w, LogEvent{Type: "boss_defeated", Actor: w.Party[i].Name, Target: enemy})
+					} else {
+						emit(w, LogEvent{Type: "enemy_defeated", Actor: w.Party[i].Name, Target: enemy})
+					}
 					break
 				}
 			}
@@ -459,30 +905,42 @@ func resolveCombat(w *World, seed int64) []string {
 				}
 			}
 			if len(alive) == 0 {
-				logs = append(logs, "All heroes down")
-				return logs
+				emit(w, LogEvent{Type: "party_down"})
+				return
 			}
 			target := alive[r.Intn(len(alive))]
-			hit := 6 + r.Intn(8)
+			for _, i := range alive {
+				if hasAbility(w.Party[i], "taunt") && r.Intn(2) == 0 {
+					target = i
+					break
+				}
+			}
+			hit := max(scaled(6+r.Intn(8), scale)-stat(w.Party[target], "def")/3, 1)
 			w.Party[target].HP -= hit
 			if w.Party[target].HP < 0 {
 				w.Party[target].HP = 0
 			}
-			logs = append(logs, fmt.Sprintf("Enemy hits %s for %d (HP %d)", w.Party[target].Name, hit, w.Party[target].HP))
+			emit(w, LogEvent{Type: "hero_hit", Actor: enemy, Target: w.Party[target].Name, Amount: hit, Value: w.Party[target].HP})
+			if w.Party[target].HP == 0 {
+				knockDown(w, &w.Party[target])
+			}
 		}
 	}
-	return logs
 }
 
 func randomLoot(seed int64) string {
 	r := rand.New(rand.NewSource(seed))
-	items := []string{"gold coins", "sapphire amulet", "rusty sword", "potion of healing", "weird trinket"}
+	items := []string{"gold coins", "sapphire amulet", "rusty sword", "potion of healing", "weird trinket", reviveItem,
+		"twin daggers", "runed staff", "iron buckler", "leather jerkin"}
 	return items[r.Intn(len(items))]
 }
 
-func triggerTrap(w *World, seed int64) string {
+func triggerTrap(w *World, seed int64) {
 	r := rand.New(rand.NewSource(seed))
-	damage := 5 + r.Intn(16)
+	damage := scaled(5+r.Intn(16), dangerScale(w, w.Current+1))
+	if w.Rooms[w.Current].TrapKnown {
+		damage = max(damage/2, 1)
+	}
 	alive := []int{}
 	for i := range w.Party {
 		if w.Party[i].HP > 0 {
@@ -490,17 +948,39 @@ func triggerTrap(w *World, seed int64) string {
 		}
 	}
 	if len(alive) == 0 {
-		return "Trap triggers, but no one is alive to be affected."
+		emit(w, LogEvent{Type: "trap_empty"})
+		return
+	}
+	// The nimblest hero on their feet gets a shot at disarming it first.
+	best := alive[0]
+	for _, i := range alive {
+		if stat(w.Party[i], "dex") > stat(w.Party[best], "dex") {
+			best = i
+		}
+	}
+	chance := 5 * stat(w.Party[best], "dex")
+	if hasAbility(w.Party[best], "trap_sense") {
+		chance += 30
+	}
+	if w.Rooms[w.Current].TrapKnown {
+		chance += 40
+	}
+	if r.Intn(100) < min(chance, 85) {
+		emit(w, LogEvent{Type: "trap_disarmed", Actor: w.Party[best].Name})
+		return
 	}
 	target := alive[r.Intn(len(alive))]
 	w.Party[target].HP -= damage
 	if w.Party[target].HP < 0 {
 		w.Party[target].HP = 0
 	}
-	return fmt.Sprintf("Trap triggers: %s takes %d damage (HP %d)", w.Party[target].Name, damage, w.Party[target].HP)
+	emit(w, LogEvent{Type: "trap_triggered", Target: w.Party[target].Name, Amount: damage, Value: w.Party[target].HP})
+	if w.Party[target].HP == 0 {
+		knockDown(w, &w.Party[target])
+	}
 }
 
-func restParty(w *World) int {
+func restParty(w *World) {
 	healed := 0
 	for i := range w.Party {
 		if w.Party[i].HP <= 0 {
@@ -513,7 +993,7 @@ func restParty(w *World) int {
 		w.Party[i].HP += amount
 		healed += amount
 	}
-	return healed
+	emit(w, LogEvent{Type: "rested", Amount: healed})
 }
 
 func max(a, b int) int {
@@ -521,4 +1001,4 @@ func max(a, b int) int {
 		return a
 	}
 	return b
-}
\ No newline at end of file
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// The meta layer is opt-in per world ("meta": true on generate). Ending a
+// meta run, won or lost, pays void shards into the owning profile. Shards buy
+// permanent unlocks that are applied whenever a meta world assembles its
+// party: an extra role, starting items or stat bonuses.
+
+type metaUnlock struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Desc string `json:"desc"`
+	Cost int    `json:"cost"`
+}
+
+// MetaShopItem is an unlock as seen by one profile.
+type MetaShopItem struct {
+	metaUnlock
+	Owned bool `json:"owned"`
+}
+
+var metaUnlocks = []metaUnlock{
+	{"role:ranger", "Ranger", "A ranger joins every party with room for one, in themes rangers can go", 40},
+	{"item:potion of healing", "Field Kit", "Start each run with a potion of healing", 15},
+	{"item:" + reviveItem, "Second Chance", "Start each run with a " + reviveItem, 30},
+	{"bonus:vitality", "Vitality", "+10 max HP for every hero", 25},
+	{"bonus:agility", "Agility", "+2 dex for every hero", 25},
+}
+
+// shardsEarned pays out for a finished run: a share of the score plus a flat
+// amount that is bigger for a win.
+func shardsEarned(w *World) int {
+	n := w.Score / 100
+	if w.GameState == "finished" {
+		return n + 10
+	}
+	return n + 3
+}
+
+func ownsUnlock(p *Profile, id string) bool {
+	for _, u := range p.Unlocks {
+		if u == id {
+			return true
+		}
+	}
+	return false
+}
+
+// applyMeta applies the owning profile's unlocks to a newly assembled party.
+func applyMeta(w *World) {
+	if !w.Meta {
+		return
+	}
+	applied := []string{}
+	withProfile(w, func(p *Profile) {
+		for _, u := range metaUnlocks {
+			if !ownsUnlock(p, u.ID) {
+				continue
+			}
+			switch u.ID {
+			case "role:ranger":
+				// Same rules as a party picked by hand: the class has to be
+				// available in the theme and the party can't grow past the cap.
+				c, ok := classFor("ranger")
+				if !ok || !c.availableIn(w.Theme) || len(w.Party) >= maxPartySize {
+					continue
+				}
+				w.Party = append(w.Party, heroFromClass(c, partyBase()+len(w.Party)+1))
+			case "bonus:vitality":
+				for i := range w.Party {
+					w.Party[i].MaxHP += 10
+					w.Party[i].HP += 10
+				}
+			case "bonus:agility":
+				for i := range w.Party {
+					w.Party[i].Stats["dex"] += 2
+				}
+			default: // item:<name>
+				w.Inventory = append(w.Inventory, u.ID[len("item:"):])
+			}
+			applied = append(applied, u.Name)
+		}
+	})
+	for _, name := range applied {
+		emit(w, LogEvent{Type: "meta_unlock", Detail: name})
+	}
+}
+
+// metaShopHandler lists unlocks for a profile (GET ?profile=id) or buys one
+// (POST {"profile": id, "unlock": id}).
+func metaShopHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		profilesMu.Lock()
+		defer profilesMu.Unlock()
+		p := profiles[r.URL.Query().Get("profile")]
+		if p == nil {
+			apierr.Write(w, r, apierr.New(apierr.NotFound, "profile not found"))
+			return
+		}
+		items := []MetaShopItem{}
+		for _, u := range metaUnlocks {
+			items = append(items, MetaShopItem{u, ownsUnlock(p, u.ID)})
+		}
+		writeJSON(w, map[string]interface{}{"shards": p.Shards, "unlocks": items})
+	case "POST":
+		var req struct {
+			Profile string `json:"profile"`
+			Unlock  string `json:"unlock"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierr.Write(w, r, apierr.BadBody(err))
+			return
+		}
+		profilesMu.Lock()
+		defer profilesMu.Unlock()
+		p := profiles[req.Profile]
+		if p == nil {
+			apierr.Write(w, r, apierr.New(apierr.NotFound, "profile not found"))
+			return
+		}
+		var u *metaUnlock
+		for i := range metaUnlocks {
+			if metaUnlocks[i].ID == req.Unlock {
+				u = &metaUnlocks[i]
+			}
+		}
+		if u == nil {
+			apierr.Write(w, r, apierr.Invalid("unlock", "unknown unlock: %s", req.Unlock))
+			return
+		}
+		if ownsUnlock(p, u.ID) {
+			apierr.Write(w, r, apierr.New(apierr.AlreadyUnlocked, "already unlocked"))
+			return
+		}
+		if p.Shards < u.Cost {
+			apierr.Write(w, r, apierr.New(apierr.InsufficientShards, "not enough shards: need %d, have %d", u.Cost, p.Shards))
+			return
+		}
+		p.Shards -= u.Cost
+		p.Unlocks = append(p.Unlocks, u.ID)
+		saveProfile(p)
+		writeJSON(w, p)
+	default:
+		apierr.Write(w, r, apierr.New(apierr.MethodNotAllowed, "GET or POST only"))
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+// Party morale and fatigue both run 0–100. Morale rises with loot, victories
+// and rests and falls with traps, fallen heroes and long marches without a
+// rest. Fatigue builds with every room and only rest rooms shed it. Together
+// they set how often heroes miss and how hard they hit in resolveCombat.
+
+const (
+	startMorale   = 60
+	restlessAfter = 3 // rooms without rest before morale starts draining
+)
+
+func clampMeter(v int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 100 {
+		return 100
+	}
+	return v
+}
+
+// combatModifiers returns the percent chance a hero's swing misses and the
+// multiplier applied to the damage of swings that land.
+func combatModifiers(w *World) (miss int, mult float64) {
+	miss = 10 + w.Fatigue/5 - (w.Morale-50)/10
+	if miss < 0 {
+		miss = 0
+	}
+	if miss > 50 {
+		miss = 50
+	}
+	mult = 1 + float64(w.Morale-50)/200 - float64(w.Fatigue)/250
+	return miss, mult
+}
+
+func partyStatuses(w *World) []string {
+	st := make([]string, len(w.Party))
+	for i, h := range w.Party {
+		st[i] = h.Status
+	}
+	return st
+}
+
+// updateMorale applies the morale and fatigue changes for one action. prev is
+// the room as it was before the action and before the statuses of the party.
+func updateMorale(w *World, prev Room, action string, before []string) {
+	oldMorale := w.Morale
+	resolved := prev.State != "cleared" && w.Rooms[prev.Index-1].State == "cleared"
+
+	w.Fatigue += 3
+	w.RoomsSinceRest++
+	if action == "flee" {
+		w.Morale -= 5
+		w.Fatigue += 5
+	}
+	if resolved {
+		switch prev.Type {
+		case "combat":
+			w.Morale += 8
+			w.Fatigue += 8
+		case "loot":
+			w.Morale += 5
+		case "trap":
+			w.Morale -= 6
+			w.Fatigue += 4
+		case "rest":
+			w.Morale += 10
+			w.Fatigue -= 40
+			w.RoomsSinceRest = 0
+		}
+	}
+	if w.RoomsSinceRest > restlessAfter {
+		w.Morale -= 3
+	}
+	for i, h := range w.Party {
+		if i >= len(before) || h.Status == before[i] {
+			continue
+		}
+		switch h.Status {
+		case "downed":
+			w.Morale -= 10
+		case "dead":
+			w.Morale -= 15
+		}
+	}
+	w.Morale = clampMeter(w.Morale)
+	w.Fatigue = clampMeter(w.Fatigue)
+
+	switch {
+	case oldMorale >= 25 && w.Morale < 25:
+		emit(w, LogEvent{Type: "morale", Amount: w.Morale, Detail: "shaken"})
+	case oldMorale <= 80 && w.Morale > 80:
+		emit(w, LogEvent{Type: "morale", Amount: w.Morale, Detail: "inspired"})
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// NPCs are non-hostile characters placed in quiet rooms. Each one walks a
+// small dialogue tree shared by all NPCs; the personality colours the lines
+// and decides what gift they hand over.
+
+type NPC struct {
+	ID          string           `json:"id"`
+	Name        string           `json:"name"`
+	Personality string           `json:"personality"`
+	Room        int              `json:"room"`
+	Node        string           `json:"node"`
+	Line        string           `json:"line"`
+	Options     []DialogueOption `json:"options"`
+}
+
+type DialogueOption struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+type dialogueNode struct {
+	Text    string
+	Options []dialogueChoice
+}
+
+type dialogueChoice struct {
+	DialogueOption
+	Next    string
+	Outcome string // item/quest/reveal_traps
+}
+
+type personality struct {
+	Greeting string
+	Gift     string
+}
+
+var personalities = map[string]personality{
+	"gruff":    {"\"What do you want?\" %s grunts.", "rusty sword"},
+	"cheerful": {"\"Travellers! Oh, how lovely!\" %s beams.", "potion of healing"},
+	"nervous":  {"%s flinches. \"Y-you're not with them, are you?\"", reviveItem},
+	"cryptic":  {"%s smiles thinly. \"I wondered when you would come.\"", "weird trinket"},
+}
+
+var npcNames = []string{"Alder", "Brisa", "Corvin", "Dessa", "Emrys", "Fenna", "Gideon", "Halla", "Ivo", "Juniper"}
+
+var dialogueTree = map[string]dialogueNode{
+	"start": {"", []dialogueChoice{
+		{DialogueOption{"help", "Can you help us?"}, "help", ""},
+		{DialogueOption{"path", "What lies ahead?"}, "path", ""},
+		{DialogueOption{"leave", "Farewell."}, "end", ""},
+	}},
+	"help": {"\"Help costs. Do something for me and I'll make it worth your while.\"", []dialogueChoice{
+		{DialogueOption{"accept", "Name the job."}, "end", "quest"},
+		{DialogueOption{"beg", "We need supplies now."}, "end", "item"},
+		{DialogueOption{"back", "Never mind."}, "start", ""},
+	}},
+	"path": {"\"Tread carefully. Not every floor is what it seems.\"", []dialogueChoice{
+		{DialogueOption{"traps", "Where are the traps?"}, "end", "reveal_traps"},
+		{DialogueOption{"back", "Something else."}, "start", ""},
+	}},
+	"end": {"\"Go on, then.\"", nil},
+}
+
+func placeNPCs(r *rand.Rand, rooms []Room) []NPC {
+	quiet := []int{}
+	for _, rm := range rooms {
+		if rm.Type == "loot" || rm.Type == "rest" {
+			quiet = append(quiet, rm.Index)
+		}
+	}
+	r.Shuffle(len(quiet), func(i, j int) { quiet[i], quiet[j] = quiet[j], quiet[i] })
+	kinds := []string{"gruff", "cheerful", "nervous", "cryptic"}
+	npcs := []NPC{}
+	for i := 0; i < len(quiet) && i < 1+r.Intn(2); i++ {
+		n := NPC{
+			ID:          fmt.Sprintf("npc-%d", i+1),
+			Name:        npcNames[r.Intn(len(npcNames))],
+			Personality: kinds[r.Intn(len(kinds))],
+			Room:        quiet[i],
+		}
+		setNode(&n, "start")
+		npcs = append(npcs, n)
+	}
+	return npcs
+}
+
+func setNode(n *NPC, node string) {
+	n.Node = node
+	d := dialogueTree[node]
+	n.Line = d.Text
+	if node == "start" {
+		n.Line = fmt.Sprintf(personalities[n.Personality].Greeting, n.Name)
+	}
+	n.Options = []DialogueOption{}
+	for _, c := range d.Options {
+		n.Options = append(n.Options, c.DialogueOption)
+	}
+}
+
+// npcsIn announces anyone waiting in the given room.
+func npcsIn(w *World, room int) {
+	for _, n := range w.NPCs {
+		if n.Room == room {
+			emit(w, LogEvent{Type: "npc_present", Actor: n.Name, Detail: n.Personality})
+		}
+	}
+}
+
+// talk advances an NPC's dialogue by one choice and applies its outcome.
+func talk(w *World, n *NPC, option string) error {
+	var picked *dialogueChoice
+	for _, c := range dialogueTree[n.Node].Options {
+		if c.ID == option {
+			picked = &c
+			break
+		}
+	}
+	if picked == nil {
+		if len(n.Options) == 0 {
+			return fmt.Errorf("%s has nothing more to say", n.Name)
+		}
+		ids := []string{}
+		for _, o := range n.Options {
+			ids = append(ids, o.ID)
+		}
+		return apierr.Invalid("option", "option must be one of %s", strings.Join(ids, ", "))
+	}
+	emit(w, LogEvent{Type: "talk", Target: n.Name, Detail: picked.Label})
+	setNode(n, picked.Next)
+	switch picked.Outcome {
+	case "item":
+		gift := personalities[n.Personality].Gift
+		w.Inventory = append(w.Inventory, gift)
+		emit(w, LogEvent{Type: "item_gained", Actor: n.Name, Detail: gift})
+	case "quest":
+		npcQuest(w, n)
+	case "reveal_traps":
+		found := []string{}
+		for i := range w.Rooms {
+			if w.Rooms[i].Type == "trap" && w.Rooms[i].State != "cleared" {
+				w.Rooms[i].TrapKnown = true
+				found = append(found, fmt.Sprint(w.Rooms[i].Index))
+			}
+		}
+		if len(found) == 0 {
+			emit(w, LogEvent{Type: "npc_line", Actor: n.Name, Detail: "No traps left that I know of."})
+		} else {
+			emit(w, LogEvent{Type: "traps_marked", Actor: n.Name, Detail: strings.Join(found, ", ")})
+		}
+	}
+	if n.Line != "" {
+		emit(w, LogEvent{Type: "npc_line", Actor: n.Name, Detail: n.Line})
+	}
+	return nil
+}
+
+// npcQuest asks the party to recover a keepsake from a room further in. If
+// there's nothing left ahead, the NPC pays up front instead.
+func npcQuest(w *World, n *NPC) {
+	ahead := []int{}
+	for _, rm := range w.Rooms[w.Current:] {
+		if rm.State != "cleared" {
+			ahead = append(ahead, rm.Index)
+		}
+	}
+	if len(ahead) == 0 {
+		gift := personalities[n.Personality].Gift
+		w.Inventory = append(w.Inventory, gift)
+		emit(w, LogEvent{Type: "item_gained", Actor: n.Name, Detail: gift})
+		return
+	}
+	room := ahead[len(ahead)/2]
+	o := Objective{
+		Kind:   "retrieve",
+		Desc:   fmt.Sprintf("Recover %s's keepsake from room %d", n.Name, room),
+		Target: 1,
+		Room:   room,
+		Status: "active",
+		Reward: personalities[n.Personality].Gift,
+	}
+	w.Objectives = append(w.Objectives, o)
+	emit(w, LogEvent{Type: "objective_added", Detail: o.Desc})
+}
+
+func talkHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		apierr.Write(w, r, apierr.New(apierr.MethodNotAllowed, "POST only"))
+		return
+	}
+	var req struct {
+		ID     string `json:"id"`
+		NPC    string `json:"npc"`
+		Option string `json:"option"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, r, apierr.BadBody(err))
+		return
+	}
+	storeMu.Lock()
+	wld, ok := store[req.ID]
+	storeMu.Unlock()
+	if !ok {
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "session not found"))
+		return
+	}
+	if err := talkTo(wld, req.NPC, req.Option); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeView(w, wld)
+}
+
+// talkTo picks an option in the dialogue of an NPC in the party's room.
+func talkTo(wld *World, id, option string) error {
+	worldMu.Lock()
+	defer worldMu.Unlock()
+	if err := runOver(wld); err != nil {
+		return err
+	}
+	var npc *NPC
+	for i := range wld.NPCs {
+		if wld.NPCs[i].ID == id {
+			npc = &wld.NPCs[i]
+		}
+	}
+	if npc == nil {
+		return apierr.New(apierr.NotFound, "npc not found")
+	}
+	// The party is standing in the last room it entered.
+	if npc.Room != wld.Current {
+		return apierr.New(apierr.WrongRoom, "%s is in room %d", npc.Name, npc.Room)
+	}
+	if err := talk(wld, npc, option); err != nil {
+		return err
+	}
+	publishState(wld)
+	return nil
+}
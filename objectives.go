@@ -0,0 +1,160 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Objectives give a world goals beyond reaching the last room. They are
+// picked from the prompt, falling back to a default per theme, and their
+// progress is recomputed from world state after every action.
+
+type Objective struct {
+	Kind     string `json:"kind"` // retrieve/rescue/defeat/escape
+	Desc     string `json:"desc"`
+	Target   int    `json:"target"`
+	Progress int    `json:"progress"`
+	Room     int    `json:"room,omitempty"` // room index for retrieve/rescue
+	Status   string `json:"status"`         // active/complete/failed
+	Reward   string `json:"reward,omitempty"`
+}
+
+var captiveNames = []string{"Mira", "Old Tobin", "Sela", "Dax", "Iri", "Brother Anselm", "Kestrel"}
+
+func buildObjectives(r *rand.Rand, prompt, theme string, rooms []Room) []Objective {
+	p := strings.ToLower(prompt)
+	has := func(words ...string) bool {
+		for _, w := range words {
+			if strings.Contains(p, w) {
+				return true
+			}
+		}
+		return false
+	}
+	kinds := []string{}
+	if has("relic", "artifact", "treasure", "steal", "heist", "retrieve") {
+		kinds = append(kinds, "retrieve")
+	}
+	if has("rescue", "prisoner", "captive", "hostage", "save") {
+		kinds = append(kinds, "rescue")
+	}
+	if has("monster", "hunt", "kill", "slay", "fight", "battle") {
+		kinds = append(kinds, "defeat")
+	}
+	if has("escape", "race", "hurry", "timed", "chase") {
+		kinds = append(kinds, "escape")
+	}
+	if len(kinds) == 0 {
+		switch theme {
+		case "dungeon", "cyberpunk":
+			kinds = append(kinds, "retrieve")
+		case "space":
+			kinds = append(kinds, "rescue")
+		case "city":
+			kinds = append(kinds, "escape")
+		default:
+			kinds = append(kinds, "defeat")
+		}
+	}
+
+	a := adapterFor(theme)
+	objs := []Objective{}
+	for _, k := range kinds {
+		o := Objective{Kind: k, Target: 1, Status: "active"}
+		switch k {
+		case "retrieve":
+			o.Room = pickObjectiveRoom(r, rooms, "loot")
+			o.Desc = fmt.Sprintf("Retrieve the %s from room %d", a.Relic, o.Room)
+			o.Reward = a.Relic
+		case "rescue":
+			o.Room = pickObjectiveRoom(r, rooms, "combat")
+			o.Desc = fmt.Sprintf("Rescue %s, held in room %d", captiveNames[r.Intn(len(captiveNames))], o.Room)
+		case "defeat":
+			combats := 0
+			for _, rm := range rooms {
+				if rm.Type == "combat" {
+					combats++
+				}
+			}
+			o.Target = max(combats, 1)
+			o.Desc = fmt.Sprintf("Defeat %d enemies", o.Target)
+			if o.Target == 1 {
+				o.Desc = "Defeat an enemy"
+			}
+		case "escape":
+			o.Target = len(rooms) + 2
+			o.Desc = fmt.Sprintf("Reach the end within %d moves", o.Target)
+		}
+		objs = append(objs, o)
+	}
+	return objs
+}
+
+// pickObjectiveRoom prefers a room of type t in the back half of the dungeon,
+// falling back to any room there.
+func pickObjectiveRoom(r *rand.Rand, rooms []Room, t string) int {
+	half := rooms[len(rooms)/2:]
+	candidates := []int{}
+	for _, rm := range half {
+		if rm.Type == t {
+			candidates = append(candidates, rm.Index)
+		}
+	}
+	if len(candidates) == 0 {
+		return half[r.Intn(len(half))].Index
+	}
+	return candidates[r.Intn(len(candidates))]
+}
+
+// updateObjectives refreshes progress and settles objectives once the run is
+// over, emitting an event for each status change.
+func updateObjectives(w *World) {
+	for i := range w.Objectives {
+		o := &w.Objectives[i]
+		if o.Status != "active" {
+			continue
+		}
+		switch o.Kind {
+		case "retrieve", "rescue":
+			switch w.Rooms[o.Room-1].State {
+			case "cleared":
+				o.Progress = 1
+			case "unresolved":
+				// Running past the captive leaves them behind.
+				if o.Kind == "rescue" && w.Current >= o.Room {
+					o.Status = "failed"
+				}
+			}
+		case "defeat":
+			o.Progress = min(w.Kills, o.Target)
+		case "escape":
+			o.Progress = w.Moves
+			if w.Moves > o.Target {
+				o.Status = "failed"
+			}
+		}
+		if o.Status == "active" {
+			switch {
+			case o.Kind == "escape":
+				if w.GameState == "finished" {
+					o.Status = "complete"
+				}
+			case o.Progress >= o.Target:
+				o.Status = "complete"
+			}
+		}
+		if o.Status == "active" && (w.GameState == "finished" || w.GameState == "game_over") {
+			o.Status = "failed"
+		}
+		switch o.Status {
+		case "complete":
+			emit(w, LogEvent{Type: "objective_complete", Detail: o.Desc})
+			if o.Reward != "" {
+				w.Inventory = append(w.Inventory, o.Reward)
+			}
+		case "failed":
+			emit(w, LogEvent{Type: "objective_failed", Detail: o.Desc})
+		}
+	}
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// GET /openapi.json describes the API as an OpenAPI 3.0 document, for
+// generating typed clients. The routes are listed by hand in openAPIPaths and
+// have to follow routes(), but every body schema is reflected from the Go type
+// the handler decodes or encodes, so a new or renamed field shows up without
+// touching this file. Response schemas mark the fields that are always sent as
+// required; request schemas never do, since a missing field is its zero value.
+
+var (
+	openAPIOnce sync.Once
+	openAPIJSON []byte
+)
+
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	openAPIOnce.Do(func() {
+		openAPIJSON, _ = json.MarshalIndent(openAPIDoc(), "", "  ")
+	})
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPIJSON)
+}
+
+func openAPIDoc() map[string]any {
+	g := &schemaGen{defs: map[string]any{}}
+	paths := openAPIPaths(g)
+
+	errSchema := g.of(reflect.TypeOf(apierr.Error{}), false)
+	if props, ok := g.defs["Error"].(map[string]any)["properties"].(map[string]any); ok {
+		props["code"].(map[string]any)["enum"] = apierr.Codes()
+	}
+	g.defs["ErrorEnvelope"] = map[string]any{
+		"type":       "object",
+		"properties": map[string]any{"error": errSchema},
+		"required":   []string{"error"},
+	}
+	// The WebSocket messages aren't bodies of any operation, but clients
+	// still want their types.
+	g.of(reflect.TypeOf(wsRequest{}), true)
+	g.of(reflect.TypeOf(wsReply{}), false)
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       "Void Spark",
+			"version":     "1",
+			"description": "Prompt to world engine: generate a dungeon from a prompt, assemble a party and play it room by room.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": g.defs,
+			"responses": map[string]any{
+				"Error": map[string]any{
+					"description": "Error envelope; see the code for what went wrong.",
+					"content":     jsonContent(ref("ErrorEnvelope")),
+				},
+			},
+			"parameters": map[string]any{
+				"id": map[string]any{
+					"name": "id", "in": "path", "required": true,
+					"schema": map[string]any{"type": "string"},
+				},
+			},
+		},
+	}
+}
+
+// openAPIPaths lists every API route. The UI ("/"), /web/ and the saved world
+// files under /worlds/ are left out.
+func openAPIPaths(g *schemaGen) map[string]map[string]any {
+	world := reflect.TypeOf(World{})
+	scores := reflect.TypeOf([]ScoreEntry{})
+	profile := reflect.TypeOf(Profile{})
+	viewParams := []any{
+		query("view", "debug for the full world, fog of war lifted; needs X-Debug-Token", "string", "debug"),
+		map[string]any{"name": "X-Debug-Token", "in": "header", "schema": map[string]any{"type": "string"}},
+	}
+	idParam := map[string]any{"$ref": "#/components/parameters/id"}
+
+	worldsGet := g.op("worlds", "List worlds in memory and on disk", nil, http.StatusOK, reflect.TypeOf(WorldPage{}),
+		query("theme", "exact theme, any case", "string"),
+		query("aesthetic", "exact aesthetic, any case", "string"),
+		query("dimension", "exact dimension, any case", "string"),
+		query("game_state", "exact game state, any case", "string"),
+		query("q", "substring of the prompt, any case", "string"),
+		query("created_after", "RFC 3339 time", "date-time"),
+		query("created_before", "RFC 3339 time", "date-time"),
+		query("sort", "default created", "string", "created", "prompt", "score", "theme"),
+		query("order", "default desc for created and score, asc otherwise", "string", "asc", "desc"),
+		query("limit", "worlds per page, default 20, at most 100", "integer"),
+		query("cursor", "next_cursor of the previous page", "string"),
+	)
+	worldsPost := g.op("worlds", "Generate a world", reflect.TypeOf(worldOptions{}), http.StatusCreated, world)
+	worldsPost["responses"].(map[string]any)["201"].(map[string]any)["headers"] = map[string]any{
+		"Location": map[string]any{"schema": map[string]any{"type": "string"}, "description": "/worlds/{id}"},
+	}
+	worldGet := g.op("worlds", "Player view of a world", nil, http.StatusOK, world, append([]any{idParam}, viewParams...)...)
+	worldDelete := g.op("worlds", "Delete a world from memory and disk", nil, http.StatusNoContent, nil, idParam)
+	worldDelete["description"] = "Anyone following the world is disconnected."
+	party := g.op("worlds", "Assemble the party", reflect.TypeOf(struct {
+		Roles []string `json:"roles"`
+	}{}), http.StatusOK, world, idParam)
+	party["requestBody"].(map[string]any)["required"] = false
+	party["description"] = "An empty body asks for the default party; see GET /classes for roles. Asking again is a no-op."
+	actions := g.op("worlds", "Explore, flee, choose, revive, talk or equip", reflect.TypeOf(Action{}), http.StatusOK, world, idParam)
+	events := g.op("worlds", "Follow a world as Server-Sent Events", nil, http.StatusOK, nil, idParam,
+		map[string]any{"name": "Last-Event-ID", "in": "header", "schema": map[string]any{"type": "integer"}},
+		query("last_event_id", "same as Last-Event-ID, for a fresh EventSource", "integer"),
+	)
+	events["description"] = "\"event\" messages carry a LogEvent with its seq as the SSE id; \"state\" messages carry the top-level fields of the player view that changed, removed ones as null."
+	events["responses"].(map[string]any)["200"] = map[string]any{
+		"description": "event stream",
+		"content":     map[string]any{"text/event-stream": map[string]any{"schema": map[string]any{"type": "string"}}},
+	}
+	ws := g.op("worlds", "Play over a WebSocket", nil, http.StatusSwitchingProtocols, nil)
+	ws["description"] = "Client messages are WsRequest (subscribe or act), server messages are WsReply (state, event or error)."
+
+	daily := map[string]any{
+		"get": g.op("scores", "Today's challenge", nil, http.StatusOK, reflect.TypeOf(dailyChallenge{})),
+		"post": g.op("scores", "Start a player's one attempt at today's challenge", reflect.TypeOf(struct {
+			Player string `json:"player"`
+		}{}), http.StatusOK, world),
+	}
+	profilesGet := g.op("profiles", "List profiles, or fetch one by id", nil, http.StatusOK, nil,
+		query("id", "profile to fetch", "string"))
+	profilesGet["responses"].(map[string]any)["200"] = map[string]any{
+		"description": "a list of summaries, or the profile when id is given",
+		"content": jsonContent(map[string]any{"oneOf": []any{
+			g.of(reflect.TypeOf([]ProfileSummary{}), false),
+			g.of(profile, false),
+		}}),
+	}
+
+	return map[string]map[string]any{
+		"/worlds":              {"get": worldsGet, "post": worldsPost},
+		"/worlds/{id}":         {"get": worldGet, "delete": worldDelete},
+		"/worlds/{id}/party":   {"post": party},
+		"/worlds/{id}/actions": {"post": actions},
+		"/worlds/{id}/events":  {"get": events},
+		"/ws":                  {"get": ws},
+		"/generate":            {"post": g.op("legacy", "Generate a world", reflect.TypeOf(worldOptions{}), http.StatusOK, world)},
+		"/party": {"post": g.op("legacy", "Assemble the party", reflect.TypeOf(struct {
+			ID    string   `json:"id"`
+			Roles []string `json:"roles"`
+		}{}), http.StatusOK, world)},
+		"/explore": {"post": g.op("legacy", "Explore, flee, choose or revive", reflect.TypeOf(struct {
+			ID string `json:"id"`
+			Move
+		}{}), http.StatusOK, world)},
+		"/state": {"get": g.op("legacy", "Player view of a world", nil, http.StatusOK, world,
+			append([]any{query("id", "world id", "string")}, viewParams...)...)},
+		"/talk": {"post": g.op("legacy", "Pick a dialogue option", reflect.TypeOf(struct {
+			ID     string `json:"id"`
+			NPC    string `json:"npc"`
+			Option string `json:"option"`
+		}{}), http.StatusOK, world)},
+		"/equip": {"post": g.op("legacy", "Equip an item, or unequip a slot", reflect.TypeOf(struct {
+			ID   string `json:"id"`
+			Hero string `json:"hero"`
+			Item string `json:"item"`
+			Slot string `json:"slot"`
+		}{}), http.StatusOK, world)},
+		"/leaderboard": {"get": g.op("scores", "Top scores", nil, http.StatusOK, scores,
+			query("seed", "only runs of this seed", "integer"),
+			query("limit", "default 10", "integer"))},
+		"/daily": daily,
+		"/daily/leaderboard": {"get": g.op("scores", "Top scores of a daily challenge", nil, http.StatusOK, scores,
+			query("date", "YYYY-MM-DD, default today", "string"))},
+		"/achievements": {"get": g.op("profiles", "Achievements of a profile", nil, http.StatusOK, reflect.TypeOf([]AchievementStatus{}),
+			query("profile", "profile id", "string"))},
+		"/profiles": {"get": profilesGet, "post": g.op("profiles", "Create a profile", reflect.TypeOf(struct {
+			Name string `json:"name"`
+		}{}), http.StatusCreated, profile)},
+		"/profiles/favorites": {"post": g.op("profiles", "Add or remove a favourite seed", reflect.TypeOf(struct {
+			ID     string `json:"id"`
+			Seed   int64  `json:"seed"`
+			Remove bool   `json:"remove"`
+		}{}), http.StatusOK, profile)},
+		"/meta/shop": {
+			"get": g.op("profiles", "Meta unlocks as seen by a profile", nil, http.StatusOK, reflect.TypeOf(struct {
+				Shards  int            `json:"shards"`
+				Unlocks []MetaShopItem `json:"unlocks"`
+			}{}), query("profile", "profile id", "string")),
+			"post": g.op("profiles", "Buy a meta unlock", reflect.TypeOf(struct {
+				Profile string `json:"profile"`
+				Unlock  string `json:"unlock"`
+			}{}), http.StatusOK, profile),
+		},
+		"/classes": {"get": g.op("worlds", "Hero classes", nil, http.StatusOK, reflect.TypeOf([]HeroClass{}),
+			query("theme", "only classes available in this theme", "string"))},
+		"/api/latest-world": {"get": g.op("worlds", "Id of the newest saved world", nil, http.StatusOK, reflect.TypeOf(struct {
+			Latest string `json:"latest"`
+		}{}))},
+		"/openapi.json": {"get": g.op("meta", "This document", nil, http.StatusOK, nil)},
+	}
+}
+
+// schemaGen reflects Go types into JSON schemas, collecting named structs under
+// components/schemas.
+type schemaGen struct {
+	defs map[string]any
+}
+
+// op describes one operation. req and resp are the body types, or nil for
+// none; any failure answers with the error envelope.
+func (g *schemaGen) op(tag, summary string, req reflect.Type, status int, resp reflect.Type, params ...any) map[string]any {
+	ok := map[string]any{"description": http.StatusText(status)}
+	if resp != nil {
+		ok["content"] = jsonContent(g.of(resp, false))
+	}
+	op := map[string]any{
+		"tags":    []string{tag},
+		"summary": summary,
+		"responses": map[string]any{
+			strconv.Itoa(status): ok,
+			"default":            map[string]any{"$ref": "#/components/responses/Error"},
+		},
+	}
+	if req != nil {
+		op["requestBody"] = map[string]any{"required": true, "content": jsonContent(g.of(req, true))}
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	return op
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	rawType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// of returns the schema for t, a $ref for named structs.
+func (g *schemaGen) of(t reflect.Type, input bool) map[string]any {
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case rawType:
+		return map[string]any{} // any JSON value
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return g.of(t.Elem(), input)
+	case reflect.Slice, reflect.Array:
+		// Nil slices and maps are sent as null.
+		return map[string]any{"type": "array", "items": g.of(t.Elem(), input), "nullable": !input}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": g.of(t.Elem(), input), "nullable": !input}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.object(t, input)
+		}
+		name := schemaName(t)
+		if _, ok := g.defs[name]; !ok {
+			g.defs[name] = nil // placeholder, in case the type refers to itself
+			g.defs[name] = g.object(t, input)
+		}
+		return ref(name)
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	}
+	return map[string]any{}
+}
+
+func (g *schemaGen) object(t reflect.Type, input bool) map[string]any {
+	props := map[string]any{}
+	required := []string{}
+	g.fields(t, input, props, &required)
+	s := map[string]any{"type": "object", "properties": props}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// fields adds the JSON fields of struct t the way encoding/json sees them:
+// untagged embedded structs are flattened and "-" and unexported fields are
+// skipped.
+func (g *schemaGen) fields(t reflect.Type, input bool, props map[string]any, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				g.fields(ft, input, props, required)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.of(f.Type, input)
+		if !input && !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
+
+// schemaName is the type's name with its first letter upper-cased, so
+// unexported types like worldOptions get proper names too.
+func schemaName(t reflect.Type) string {
+	r := []rune(t.Name())
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// query describes a query parameter. typ is a JSON schema type, or date-time;
+// any values are its enum.
+func query(name, desc, typ string, values ...string) map[string]any {
+	schema := map[string]any{"type": typ}
+	if typ == "date-time" {
+		schema = map[string]any{"type": "string", "format": "date-time"}
+	}
+	if len(values) > 0 {
+		schema["enum"] = values
+	}
+	return map[string]any{"name": name, "in": "query", "description": desc, "schema": schema}
+}
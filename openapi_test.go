@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// undocumented are the routes() patterns openAPIPaths leaves out on purpose.
+var undocumented = map[string]bool{"/": true, "/{$}": true, "/web/": true}
+
+// routePatterns reads the patterns registered in routes() from main.go, so a
+// route added there without a matching entry in openAPIPaths fails the test.
+func routePatterns(t *testing.T) []string {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), "main.go", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var patterns []string
+	for _, d := range f.Decls {
+		fn, ok := d.(*ast.FuncDecl)
+		if !ok || fn.Name.Name != "routes" {
+			continue
+		}
+		ast.Inspect(fn.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) == 0 {
+				return true
+			}
+			sel, ok := call.Fun.(*ast.SelectorExpr)
+			if !ok || (sel.Sel.Name != "Handle" && sel.Sel.Name != "HandleFunc") {
+				return true
+			}
+			if lit, ok := call.Args[0].(*ast.BasicLit); ok && lit.Kind == token.STRING {
+				p, _ := strconv.Unquote(lit.Value)
+				patterns = append(patterns, p)
+			}
+			return true
+		})
+	}
+	if len(patterns) == 0 {
+		t.Fatal("no patterns found in routes()")
+	}
+	return patterns
+}
+
+func TestOpenAPIPaths(t *testing.T) {
+	saved := cfg
+	cfg.DataDir = t.TempDir()
+	defer func() { cfg = saved }()
+	if err := os.MkdirAll(dataPath("worlds"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	paths := openAPIPaths(&schemaGen{defs: map[string]any{}})
+	served := map[string]bool{}
+	for _, p := range routePatterns(t) {
+		served[p] = true
+		method, path, ok := strings.Cut(p, " ")
+		if !ok {
+			method, path = "", p
+		}
+		if undocumented[path] {
+			continue
+		}
+		ops, ok := paths[path]
+		switch {
+		case !ok:
+			t.Errorf("%s is served but not documented", p)
+		case method != "" && ops[strings.ToLower(method)] == nil:
+			t.Errorf("%s is served but not documented", p)
+		case len(ops) == 0:
+			t.Errorf("%s is documented without any method", path)
+		}
+	}
+
+	mux := routes()
+	for path, ops := range paths {
+		for method := range ops {
+			m := strings.ToUpper(method)
+			req := httptest.NewRequest(m, strings.ReplaceAll(path, "{id}", "nope"), nil)
+			_, pattern := mux.Handler(req)
+			if pattern == "" || undocumented[pattern] || !served[pattern] {
+				t.Errorf("%s %s is documented but not served (matched %q)", m, path, pattern)
+				continue
+			}
+			if strings.Contains(pattern, " ") {
+				continue
+			}
+			// The pattern takes any method; the handler checks it.
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			if rec.Code == http.StatusMethodNotAllowed {
+				t.Errorf("%s %s is documented but answers 405", m, path)
+			}
+		}
+	}
+}
+
+// jsonFields lists the JSON names encoding/json uses for the fields of t.
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		switch {
+		case name == "-":
+		case f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct:
+			names = append(names, jsonFields(f.Type)...)
+		case !f.IsExported():
+		case name == "":
+			names = append(names, f.Name)
+		default:
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	schemas := openAPIDoc()["components"].(map[string]any)["schemas"].(map[string]any)
+	for _, v := range []any{World{}, Room{}, Hero{}} {
+		typ := reflect.TypeOf(v)
+		schema, _ := schemas[typ.Name()].(map[string]any)
+		props, _ := schema["properties"].(map[string]any)
+		if props == nil {
+			t.Errorf("no %s schema", typ.Name())
+			continue
+		}
+		for _, name := range jsonFields(typ) {
+			if _, ok := props[name]; !ok {
+				t.Errorf("%s schema is missing %q", typ.Name(), name)
+			}
+		}
+	}
+}
+
+func TestOpenAPIMarshals(t *testing.T) {
+	data, err := json.Marshal(openAPIDoc())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Components map[string]map[string]any `json:"components"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Components["schemas"]) == 0 {
+		t.Fatal("components.schemas is empty")
+	}
+	for kind, entries := range doc.Components {
+		if entries == nil {
+			t.Errorf("components.%s is null", kind)
+		}
+		for name, v := range entries {
+			if v == nil {
+				t.Errorf("components.%s.%s is null", kind, name)
+			}
+		}
+	}
+}
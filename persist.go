@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+)
+
+// Worlds are written to worlds/world_<id>.json when they are generated. Play
+// after that only changes the copy in memory, so every world that emits an
+// event is marked dirty and flushWorlds writes those back when the server
+// shuts down.
+
+var (
+	dirty   = map[string]bool{}
+	dirtyMu sync.Mutex
+)
+
+func init() {
+	eventListeners = append(eventListeners, func(w *World, e LogEvent) { markDirty(w.ID) })
+}
+
+func markDirty(id string) {
+	dirtyMu.Lock()
+	dirty[id] = true
+	dirtyMu.Unlock()
+}
+
+func saveWorld(w *World) error {
+	// The dirty flag is cleared along with the snapshot, so an action that
+	// lands while the file is being written marks the world again.
+	worldMu.Lock()
+	data, err := json.MarshalIndent(w, "", "  ")
+	if err == nil {
+		dirtyMu.Lock()
+		delete(dirty, w.ID)
+		dirtyMu.Unlock()
+	}
+	worldMu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(dataPath("worlds", "world_"+w.ID+".json"), data, 0644); err != nil {
+		markDirty(w.ID)
+		return err
+	}
+	return nil
+}
+
+// flushWorlds saves every dirty world still in the store. Deleted worlds are
+// skipped.
+func flushWorlds() {
+	dirtyMu.Lock()
+	ids := []string{}
+	for id := range dirty {
+		ids = append(ids, id)
+	}
+	dirtyMu.Unlock()
+
+	saved := 0
+	for _, id := range ids {
+		storeMu.Lock()
+		w, ok := store[id]
+		storeMu.Unlock()
+		if !ok {
+			continue
+		}
+		if err := saveWorld(w); err != nil {
+			log.Printf("failed to save world %s: %v", id, err)
+			continue
+		}
+		saved++
+	}
+	log.Printf("saved %d world(s)", saved)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+func TestRefusedMove(t *testing.T) {
+	loadClasses()
+	w := buildWorld("a dark dungeon", "dungeon", "dark", "2D", "normal", 3)
+	w.Rooms[0].Type = "loot"
+	if err := assembleParty(w, nil); err != nil {
+		t.Fatal(err)
+	}
+	wake := subscribe(w)
+	defer unsubscribe(w.ID, wake)
+
+	moves := []Move{
+		{Action: "bogus"},
+		{Action: "flee"},
+		{Action: "flee", Direction: "sideways"},
+		{Action: "choose", Choice: "pray"},
+		{Action: "revive", Hero: w.Party[0].Name},
+		{Action: "revive", Hero: "nobody"},
+	}
+	for _, m := range moves {
+		events := len(w.Events)
+		if err := play(w, m); err == nil {
+			t.Errorf("%+v: accepted", m)
+		}
+		if w.Moves != 0 || len(w.Events) != events || w.Current != 0 {
+			t.Errorf("%+v: moves %d, %d new events, room %d; want nothing to change",
+				m, w.Moves, len(w.Events)-events, w.Current)
+		}
+		select {
+		case <-wake:
+			t.Errorf("%+v: followers were sent a new state", m)
+		default:
+		}
+	}
+
+	if err := play(w, Move{Action: "explore"}); err != nil {
+		t.Fatal(err)
+	}
+	if w.Moves != 1 {
+		t.Errorf("moves = %d after one explore, want 1", w.Moves)
+	}
+	select {
+	case <-wake:
+	default:
+		t.Error("followers weren't sent the state after an explore")
+	}
+}
+
+func TestFinishedRun(t *testing.T) {
+	loadClasses()
+	for _, state := range []string{"finished", "game_over"} {
+		w := buildWorld("a dark dungeon", "dungeon", "dark", "2D", "normal", 3)
+		if err := assembleParty(w, nil); err != nil {
+			t.Fatal(err)
+		}
+		w.GameState = state
+		w.Inventory = []string{"rusty sword"}
+		events := len(w.Events)
+		for name, err := range map[string]error{
+			"talk":  talkTo(w, "nobody", ""),
+			"equip": equipHero(w, w.Party[0].Name, "rusty sword", ""),
+		} {
+			if asAPIError(err).Code != apierr.RunOver {
+				t.Errorf("%s on a %s run: err = %v, want %s", name, state, err, apierr.RunOver)
+			}
+		}
+		if len(w.Events) != events || len(w.Inventory) != 1 {
+			t.Errorf("%s run changed: %d new events, %d items", state, len(w.Events)-events, len(w.Inventory))
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// Profiles follow a player across worlds: lifetime event counters, unlocked
+// achievements, a history of runs, favourite seeds and meta-progression. Each one is saved as
+// profiles/profile_<id>.json. Generating a world with a player name links it
+// to that player's profile, creating the profile on first use.
+
+type Profile struct {
+	ID            string               `json:"id"`
+	Name          string               `json:"name"`
+	CreatedAt     time.Time            `json:"created_at"`
+	Counters      map[string]int       `json:"counters"`
+	BestScore     int                  `json:"best_score"`
+	Unlocked      map[string]time.Time `json:"unlocked"`
+	History       []ProfileRun         `json:"history"`
+	FavoriteSeeds []int64              `json:"favorite_seeds"`
+	Shards        int                  `json:"shards"`
+	Unlocks       []string             `json:"unlocks"`
+}
+
+type ProfileRun struct {
+	WorldID    string    `json:"world_id"`
+	Seed       int64     `json:"seed,omitempty"` // filled in once the run is over
+	Theme      string    `json:"theme"`
+	Difficulty string    `json:"difficulty"`
+	Outcome    string    `json:"outcome"`
+	Score      int       `json:"score"`
+	StartedAt  time.Time `json:"started_at"`
+}
+
+// ProfileSummary is the list view of a profile.
+type ProfileSummary struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	Runs      int    `json:"runs"`
+	Wins      int    `json:"wins"`
+	BestScore int    `json:"best_score"`
+}
+
+const profilesDir = "profiles"
+
+var (
+	profiles   = map[string]*Profile{}
+	profilesMu sync.Mutex
+)
+
+var nonSlug = regexp.MustCompile(`[^a-z0-9]+`)
+
+// profileID turns a player name into the profile's ID.
+func profileID(name string) string {
+	return strings.Trim(nonSlug.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func newProfile(name string) *Profile {
+	return &Profile{
+		ID:            profileID(name),
+		Name:          strings.TrimSpace(name),
+		CreatedAt:     time.Now(),
+		Counters:      map[string]int{},
+		Unlocked:      map[string]time.Time{},
+		History:       []ProfileRun{},
+		FavoriteSeeds: []int64{},
+		Unlocks:       []string{},
+	}
+}
+
+func loadProfiles() {
+	files, err := filepath.Glob(dataPath(profilesDir, "profile_*.json"))
+	if err != nil {
+		return
+	}
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	for _, f := range files {
+		data, err := os.ReadFile(f)
+		if err != nil {
+			log.Printf("failed to read profile %s: %v", f, err)
+			continue
+		}
+		p := &Profile{}
+		if err := json.Unmarshal(data, p); err != nil {
+			log.Printf("failed to parse profile %s: %v", f, err)
+			continue
+		}
+		// Hand-edited or older files may have nulls where newProfile makes
+		// empty maps and lists; the rest of the code writes to them freely.
+		if p.Counters == nil {
+			p.Counters = map[string]int{}
+		}
+		if p.Unlocked == nil {
+			p.Unlocked = map[string]time.Time{}
+		}
+		if p.History == nil {
+			p.History = []ProfileRun{}
+		}
+		if p.FavoriteSeeds == nil {
+			p.FavoriteSeeds = []int64{}
+		}
+		if p.Unlocks == nil {
+			p.Unlocks = []string{}
+		}
+		profiles[p.ID] = p
+	}
+}
+
+// saveProfile writes p to disk; callers hold profilesMu.
+func saveProfile(p *Profile) {
+	data, _ := json.MarshalIndent(p, "", "  ")
+	if err := os.MkdirAll(dataPath(profilesDir), 0755); err != nil {
+		log.Printf("failed to create profiles folder: %v", err)
+		return
+	}
+	path := dataPath(profilesDir, "profile_"+p.ID+".json")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		log.Printf("failed to save profile %s: %v", p.ID, err)
+	}
+}
+
+// linkProfile attaches a freshly created world to its player's profile.
+// Anonymous worlds have no profile.
+func linkProfile(w *World) {
+	id := profileID(w.Player)
+	if id == "" || id == "anonymous" {
+		return
+	}
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	p := profiles[id]
+	if p == nil {
+		p = newProfile(w.Player)
+		profiles[id] = p
+	}
+	w.Profile = p.ID
+	w.Player = p.Name
+	p.History = append(p.History, ProfileRun{
+		WorldID:    w.ID,
+		Theme:      w.Theme,
+		Difficulty: normalizeDifficulty(w.Difficulty),
+		Outcome:    w.GameState,
+		StartedAt:  time.Now(),
+	})
+	saveProfile(p)
+}
+
+// withProfile runs fn on the profile owning w, if any, and saves it.
+func withProfile(w *World, fn func(p *Profile)) {
+	if w.Profile == "" {
+		return
+	}
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	p := profiles[w.Profile]
+	if p == nil {
+		return
+	}
+	fn(p)
+	saveProfile(p)
+}
+
+// recordProfileRun stores the outcome of a finished run in its history.
+func recordProfileRun(w *World) {
+	paid, earned, total := false, 0, 0
+	withProfile(w, func(p *Profile) {
+		p.Counters["runs"]++
+		if w.GameState == "finished" {
+			p.Counters["wins"]++
+		}
+		p.BestScore = max(p.BestScore, w.Score)
+		if w.Meta {
+			paid, earned = true, shardsEarned(w)
+			p.Shards += earned
+			total = p.Shards
+		}
+		for i := range p.History {
+			if p.History[i].WorldID == w.ID {
+				p.History[i].Seed = w.Seed
+				p.History[i].Outcome = w.GameState
+				p.History[i].Score = w.Score
+			}
+		}
+	})
+	if paid {
+		emit(w, LogEvent{Type: "shards_earned", Amount: earned, Value: total})
+	}
+}
+
+// profilesHandler lists profiles (GET), fetches one (GET ?id=) or creates one
+// (POST {"name": ...}).
+func profilesHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		profilesMu.Lock()
+		defer profilesMu.Unlock()
+		if id := r.URL.Query().Get("id"); id != "" {
+			p := profiles[id]
+			if p == nil {
+				apierr.Write(w, r, apierr.New(apierr.NotFound, "profile not found"))
+				return
+			}
+			writeJSON(w, p)
+			return
+		}
+		out := []ProfileSummary{}
+		for _, p := range profiles {
+			out = append(out, ProfileSummary{p.ID, p.Name, p.Counters["runs"], p.Counters["wins"], p.BestScore})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+		writeJSON(w, out)
+	case "POST":
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			apierr.Write(w, r, apierr.BadBody(err))
+			return
+		}
+		id := profileID(req.Name)
+		if id == "" || id == "anonymous" {
+			apierr.Write(w, r, apierr.Invalid("name", "name required"))
+			return
+		}
+		profilesMu.Lock()
+		defer profilesMu.Unlock()
+		if profiles[id] != nil {
+			apierr.Write(w, r, apierr.New(apierr.AlreadyExists, "profile %q already exists", id))
+			return
+		}
+		p := newProfile(req.Name)
+		profiles[id] = p
+		saveProfile(p)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		writeJSON(w, p)
+	default:
+		apierr.Write(w, r, apierr.New(apierr.MethodNotAllowed, "GET or POST only"))
+	}
+}
+
+// favoriteSeedHandler adds or removes a favourite seed:
+// POST {"id": profile, "seed": n, "remove": bool}.
+func favoriteSeedHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		apierr.Write(w, r, apierr.New(apierr.MethodNotAllowed, "POST only"))
+		return
+	}
+	var req struct {
+		ID     string `json:"id"`
+		Seed   int64  `json:"seed"`
+		Remove bool   `json:"remove"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, r, apierr.BadBody(err))
+		return
+	}
+	profilesMu.Lock()
+	defer profilesMu.Unlock()
+	p := profiles[req.ID]
+	if p == nil {
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "profile not found"))
+		return
+	}
+	seeds := []int64{}
+	for _, s := range p.FavoriteSeeds {
+		if s != req.Seed {
+			seeds = append(seeds, s)
+		}
+	}
+	if !req.Remove {
+		seeds = append(seeds, req.Seed)
+	}
+	p.FavoriteSeeds = seeds
+	saveProfile(p)
+	writeJSON(w, p)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLoadProfileNulls(t *testing.T) {
+	saved := cfg
+	cfg.DataDir = t.TempDir()
+	defer func() { cfg = saved }()
+	if err := os.MkdirAll(dataPath(profilesDir), 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := `{"id": "nulls", "name": "Nulls", "counters": null, "unlocked": null, "history": null, "favorite_seeds": null, "unlocks": null}`
+	if err := os.WriteFile(dataPath(profilesDir, "profile_nulls.json"), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loadProfiles()
+	defer func() {
+		profilesMu.Lock()
+		delete(profiles, "nulls")
+		profilesMu.Unlock()
+	}()
+
+	w := buildWorld("a dark dungeon", "dungeon", "dark", "2D", "normal", 5)
+	w.Profile = "nulls"
+	// Would panic writing to a nil map.
+	onAchievementEvent(w, LogEvent{Type: "enemy_defeated"})
+
+	p := profiles["nulls"]
+	if p.Counters["enemy_defeated"] != 1 || p.Unlocked == nil || p.History == nil || p.FavoriteSeeds == nil || p.Unlocks == nil {
+		t.Errorf("profile = %+v, want every map and list made", p)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// wsClient is just enough of a WebSocket client to drive /ws from a test.
+type wsClient struct {
+	conn net.Conn
+	br   *bufio.Reader
+}
+
+func dialWS(t *testing.T, srv *httptest.Server) *wsClient {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(srv.URL, "http://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("GET", srv.URL+"/ws", nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	if err := req.Write(conn); err != nil {
+		t.Fatal(err)
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("upgrade: got %s", resp.Status)
+	}
+	return &wsClient{conn: conn, br: br}
+}
+
+// send writes v as one masked text frame. The mask is all zeros, which is
+// allowed and leaves the payload as is.
+func (c *wsClient) send(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	frame := []byte{0x80 | wsText}
+	switch n := len(data); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	default:
+		frame = append(frame, 0x80|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	}
+	frame = append(frame, 0, 0, 0, 0)
+	_, err = c.conn.Write(append(frame, data...))
+	return err
+}
+
+// reply reads server frames until the next state or error message.
+func (c *wsClient) reply() (wsReply, error) {
+	for {
+		var h [2]byte
+		if _, err := io.ReadFull(c.br, h[:]); err != nil {
+			return wsReply{}, err
+		}
+		n := uint64(h[1] & 0x7F)
+		switch n {
+		case 126:
+			var ext [2]byte
+			if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+				return wsReply{}, err
+			}
+			n = uint64(binary.BigEndian.Uint16(ext[:]))
+		case 127:
+			var ext [8]byte
+			if _, err := io.ReadFull(c.br, ext[:]); err != nil {
+				return wsReply{}, err
+			}
+			n = binary.BigEndian.Uint64(ext[:])
+		}
+		payload := make([]byte, n)
+		if _, err := io.ReadFull(c.br, payload); err != nil {
+			return wsReply{}, err
+		}
+		if h[0]&0x0F != wsText {
+			continue
+		}
+		var r wsReply
+		if err := json.Unmarshal(payload, &r); err != nil {
+			return wsReply{}, err
+		}
+		if r.Type == "state" || r.Type == "error" {
+			return r, nil
+		}
+	}
+}
+
+// TestConcurrentPlay plays one world over WebSockets and HTTP at once while
+// it is listed, viewed and saved. Run it with -race.
+func TestConcurrentPlay(t *testing.T) {
+	saved := cfg
+	cfg.DataDir = t.TempDir()
+	defer func() { cfg = saved }()
+	if err := os.MkdirAll(dataPath("worlds"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	loadClasses()
+
+	srv := httptest.NewServer(routes())
+	defer srv.Close()
+	wld := createWorld(worldOptions{Prompt: "a dark dungeon", Seed: 42})
+	defer func() {
+		storeMu.Lock()
+		delete(store, wld.ID)
+		storeMu.Unlock()
+	}()
+
+	const moves = 40
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 2; i++ {
+		c := dialWS(t, srv)
+		defer c.conn.Close()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = c.conn.SetDeadline(time.Now().Add(30 * time.Second))
+			if err := c.send(map[string]string{"type": "subscribe", "id": wld.ID}); err != nil {
+				errs <- err
+				return
+			}
+			r, err := c.reply()
+			if err != nil {
+				errs <- err
+				return
+			}
+			for j := 0; j < moves; j++ {
+				msg := map[string]string{"type": "act", "action": "explore"}
+				var event *Event
+				switch {
+				case j == 0:
+					msg["action"] = "party"
+				case r.Type == "error":
+					// Another player moved first; catch up on the state.
+					msg = map[string]string{"type": "subscribe", "id": wld.ID}
+				case json.Unmarshal(r.State["event"], &event) == nil && event != nil && len(event.Choices) > 0:
+					msg["action"], msg["choice"] = "choose", event.Choices[0].ID
+				}
+				if err := c.send(msg); err != nil {
+					errs <- err
+					return
+				}
+				if r, err = c.reply(); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	requests := []struct {
+		method, path, body string
+	}{
+		{"POST", "/worlds/" + wld.ID + "/actions", `{"action": "explore"}`},
+		{"GET", "/worlds/" + wld.ID, ""},
+		{"GET", "/worlds", ""},
+	}
+	for _, rq := range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < moves; j++ {
+				req, _ := http.NewRequest(rq.method, srv.URL+rq.path, strings.NewReader(rq.body))
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					errs <- err
+					return
+				}
+				_, _ = io.Copy(io.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for j := 0; j < moves; j++ {
+			if err := saveWorld(wld); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// Runs are scored when they end (finished or game over) and recorded on a
+// leaderboard kept in scores/leaderboard.json, so teams can compete on the
+// same seed.
+
+type ScoreEntry struct {
+	Player     string    `json:"player"`
+	WorldID    string    `json:"world_id"`
+	Seed       int64     `json:"seed"`
+	Theme      string    `json:"theme"`
+	Difficulty string    `json:"difficulty"`
+	Outcome    string    `json:"outcome"`
+	Score      int       `json:"score"`
+	Cleared    int       `json:"rooms_cleared"`
+	Moves      int       `json:"moves"`
+	Daily      string    `json:"daily,omitempty"`
+	At         time.Time `json:"at"`
+}
+
+const leaderboardFile = "scores/leaderboard.json"
+
+var (
+	leaderboard   []ScoreEntry
+	leaderboardMu sync.Mutex
+)
+
+// computeScore rewards cleared rooms, loot, surviving HP, completed objectives
+// and finishing, docks moves beyond the room count, then scales the lot by
+// the difficulty tier.
+func computeScore(w *World) int {
+	score := 0
+	for _, rm := range w.Rooms {
+		if rm.State == "cleared" {
+			score += 100
+		}
+	}
+	score += 50 * w.LootFound
+	for _, h := range w.Party {
+		score += h.HP
+	}
+	for _, o := range w.Objectives {
+		if o.Status == "complete" {
+			score += 150
+		}
+	}
+	if w.GameState == "finished" {
+		score += 500
+	}
+	if extra := w.Moves - len(w.Rooms); extra > 0 {
+		score -= 10 * extra
+	}
+	score = int(float64(score) * difficultyTiers[normalizeDifficulty(w.Difficulty)])
+	return max(score, 0)
+}
+
+func cleared(w *World) int {
+	n := 0
+	for _, rm := range w.Rooms {
+		if rm.State == "cleared" {
+			n++
+		}
+	}
+	return n
+}
+
+// settle refreshes objectives after an action and, if that action ended the
+// run, scores it and records it on the leaderboard.
+func settle(w *World) {
+	updateObjectives(w)
+	if w.GameState == "exploring" {
+		return
+	}
+	w.Score = computeScore(w)
+	recordScore(ScoreEntry{
+		Player:     w.Player,
+		WorldID:    w.ID,
+		Seed:       w.Seed,
+		Theme:      w.Theme,
+		Difficulty: normalizeDifficulty(w.Difficulty),
+		Outcome:    w.GameState,
+		Score:      w.Score,
+		Cleared:    cleared(w),
+		Moves:      w.Moves,
+		Daily:      w.Daily,
+		At:         time.Now(),
+	})
+	recordProfileRun(w)
+	emit(w, LogEvent{Type: "final_score", Amount: w.Score})
+}
+
+func loadLeaderboard() {
+	data, err := os.ReadFile(dataPath(leaderboardFile))
+	if os.IsNotExist(err) {
+		return
+	}
+	if err != nil {
+		log.Printf("failed to read leaderboard: %v", err)
+		return
+	}
+	leaderboardMu.Lock()
+	defer leaderboardMu.Unlock()
+	if err := json.Unmarshal(data, &leaderboard); err != nil {
+		log.Printf("failed to parse leaderboard: %v", err)
+	}
+}
+
+func recordScore(e ScoreEntry) {
+	leaderboardMu.Lock()
+	defer leaderboardMu.Unlock()
+	leaderboard = append(leaderboard, e)
+	data, _ := json.MarshalIndent(leaderboard, "", "  ")
+	if err := os.MkdirAll(filepath.Dir(dataPath(leaderboardFile)), 0755); err != nil {
+		log.Printf("failed to create scores folder: %v", err)
+		return
+	}
+	if err := os.WriteFile(dataPath(leaderboardFile), data, 0644); err != nil {
+		log.Printf("failed to save leaderboard: %v", err)
+	}
+}
+
+// topScores returns the best entries matching keep, highest score first.
+func topScores(keep func(ScoreEntry) bool, limit int) []ScoreEntry {
+	leaderboardMu.Lock()
+	out := []ScoreEntry{}
+	for _, e := range leaderboard {
+		if keep(e) {
+			out = append(out, e)
+		}
+	}
+	leaderboardMu.Unlock()
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Score > out[j].Score })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// leaderboardHandler serves GET /leaderboard, optionally filtered by ?seed=
+// and capped by ?limit= (default 10).
+func leaderboardHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 10
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			apierr.Write(w, r, apierr.Invalid("limit", "limit must be a positive integer"))
+			return
+		}
+		limit = n
+	}
+	keep := func(ScoreEntry) bool { return true }
+	if s := q.Get("seed"); s != "" {
+		seed, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			apierr.Write(w, r, apierr.Invalid("seed", "seed must be an integer"))
+			return
+		}
+		keep = func(e ScoreEntry) bool { return e.Seed == seed }
+	}
+	writeJSON(w, topScores(keep, limit))
+}
@@ -95,8 +95,7 @@ export default function VoidSparkUI() {
         return;
       }
       const js = await res.json();
-      const name = js.latest;
-      setOut("Latest world file: " + name);
+      setOut("Latest world: " + js.latest);
       // open preview page on backend
       window.open(`${BACKEND}/web/preview/world_preview.html`, "_blank");
     } catch (err) {
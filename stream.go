@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// Clients can follow a world live on GET /worlds/{id}/events, a Server-Sent
+// Events stream. Every LogEvent goes out as an "event" message with its Seq as
+// the SSE id, and once an action is done a "state" message carries the
+// top-level fields of the player view that changed since the client last
+// heard (removed fields are sent as null). A client that reconnects with
+// Last-Event-ID, or ?last_event_id= for a fresh EventSource, picks up from the
+// next event. Only the last feedBacklog events are kept; a client further
+// behind than that skips the ones it missed and gets the full state instead.
+
+const (
+	feedKeepAlive = 15 * time.Second
+	feedBacklog   = 256
+)
+
+// worldFeed buffers what followers of one world have yet to read. It only
+// exists while someone is following.
+type worldFeed struct {
+	events []LogEvent
+	state  map[string]json.RawMessage
+	subs   map[chan struct{}]bool
+}
+
+var (
+	feeds   = map[string]*worldFeed{}
+	feedsMu sync.Mutex
+
+	// closing is closed when the server shuts down, to end SSE streams and
+	// WebSocket sessions, which Shutdown would otherwise wait on or ignore.
+	closing     = make(chan struct{})
+	closingOnce sync.Once
+)
+
+func endStreams() { closingOnce.Do(func() { close(closing) }) }
+
+func init() {
+	eventListeners = append(eventListeners, onFeedEvent)
+}
+
+// onFeedEvent forwards an event to anyone following the world.
+func onFeedEvent(w *World, e LogEvent) {
+	feedsMu.Lock()
+	defer feedsMu.Unlock()
+	f := feeds[w.ID]
+	if f == nil {
+		return
+	}
+	if n := len(f.events); n > 0 && f.events[n-1].Seq >= e.Seq {
+		return // already copied in when the feed was opened
+	}
+	f.events = append(f.events, e)
+	if len(f.events) > feedBacklog {
+		f.events = f.events[len(f.events)-feedBacklog:]
+	}
+	f.wake()
+}
+
+// publishState snapshots the player view for followers. Handlers call it once
+// an action has been applied, so followers never see a half-resolved room.
+// Callers hold worldMu.
+func publishState(w *World) {
+	feedsMu.Lock()
+	defer feedsMu.Unlock()
+	f := feeds[w.ID]
+	if f == nil {
+		return
+	}
+	f.state = viewFields(w)
+	f.wake()
+}
+
+func (f *worldFeed) wake() {
+	for ch := range f.subs {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// viewFields splits the player view into its top-level JSON fields. Events and
+// the rendered log are left out; those travel as event messages.
+func viewFields(w *World) map[string]json.RawMessage {
+	fields := map[string]json.RawMessage{}
+	data, err := json.Marshal(playerView(w))
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	delete(fields, "events")
+	delete(fields, "log")
+	return fields
+}
+
+func subscribe(w *World) chan struct{} {
+	worldMu.Lock()
+	defer worldMu.Unlock()
+	feedsMu.Lock()
+	defer feedsMu.Unlock()
+	f := feeds[w.ID]
+	if f == nil {
+		f = &worldFeed{
+			events: append([]LogEvent(nil), w.Events[max(0, len(w.Events)-feedBacklog):]...),
+			state:  viewFields(w),
+			subs:   map[chan struct{}]bool{},
+		}
+		feeds[w.ID] = f
+	}
+	ch := make(chan struct{}, 1)
+	f.subs[ch] = true
+	return ch
+}
+
+func unsubscribe(id string, ch chan struct{}) {
+	feedsMu.Lock()
+	defer feedsMu.Unlock()
+	f := feeds[id]
+	if f == nil {
+		return
+	}
+	delete(f.subs, ch)
+	if len(f.subs) == 0 {
+		delete(feeds, id)
+	}
+}
+
+// closeFeed disconnects everyone following a world.
+func closeFeed(id string) {
+	feedsMu.Lock()
+	defer feedsMu.Unlock()
+	f := feeds[id]
+	if f == nil {
+		return
+	}
+	for ch := range f.subs {
+		close(ch)
+	}
+	delete(feeds, id)
+}
+
+// feedSince returns the events after seq and the latest state. missed is set
+// when events after seq have already dropped out of the backlog, so the
+// caller should send the full state rather than a diff.
+func feedSince(id string, seq int) (events []LogEvent, state map[string]json.RawMessage, missed bool) {
+	feedsMu.Lock()
+	defer feedsMu.Unlock()
+	f := feeds[id]
+	if f == nil {
+		return nil, nil, false
+	}
+	missed = len(f.events) > 0 && f.events[0].Seq > seq+1
+	out := []LogEvent{}
+	for _, e := range f.events {
+		if e.Seq > seq {
+			out = append(out, e)
+		}
+	}
+	return out, f.state, missed
+}
+
+// stateDiff returns the fields of next that differ from prev.
+func stateDiff(prev, next map[string]json.RawMessage) map[string]json.RawMessage {
+	diff := map[string]json.RawMessage{}
+	for k, v := range next {
+		if !bytes.Equal(prev[k], v) {
+			diff[k] = v
+		}
+	}
+	for k := range prev {
+		if _, ok := next[k]; !ok {
+			diff[k] = json.RawMessage("null")
+		}
+	}
+	return diff
+}
+
+func writeSSE(w http.ResponseWriter, id, kind string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", kind, data)
+}
+
+// worldEventsHandler serves GET /worlds/{id}/events.
+func worldEventsHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	storeMu.Lock()
+	wld, ok := store[id]
+	storeMu.Unlock()
+	if !ok {
+		apierr.Write(w, r, apierr.New(apierr.NotFound, "session not found"))
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		apierr.Write(w, r, apierr.New(apierr.Internal, "streaming unsupported"))
+		return
+	}
+	last := r.Header.Get("Last-Event-ID")
+	if last == "" {
+		last = r.URL.Query().Get("last_event_id")
+	}
+	seq := 0
+	if last != "" {
+		n, err := strconv.Atoi(last)
+		if err != nil || n < 0 {
+			apierr.Write(w, r, apierr.Invalid("Last-Event-ID", "Last-Event-ID must be an event sequence number"))
+			return
+		}
+		seq = n
+	}
+
+	// The stream outlives the server's write timeout.
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+	ch := subscribe(wld)
+	defer unsubscribe(id, ch)
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	sent := map[string]json.RawMessage{}
+	keepAlive := time.NewTicker(feedKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		events, state, missed := feedSince(id, seq)
+		if missed {
+			sent = map[string]json.RawMessage{}
+		}
+		for _, e := range events {
+			writeSSE(w, strconv.Itoa(e.Seq), "event", e)
+			seq = e.Seq
+		}
+		if diff := stateDiff(sent, state); len(diff) > 0 {
+			writeSSE(w, "", "state", diff)
+			sent = state
+		}
+		flusher.Flush()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-closing:
+			return
+		case _, ok := <-ch:
+			if !ok {
+				return // the world was deleted
+			}
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+func TestFeedBacklog(t *testing.T) {
+	w := buildWorld("a dark dungeon", "dungeon", "dark", "2D", "normal", 9)
+	for range feedBacklog {
+		emit(w, LogEvent{Type: "rested"})
+	}
+	wake := subscribe(w)
+	defer unsubscribe(w.ID, wake)
+	for range feedBacklog {
+		emit(w, LogEvent{Type: "rested"})
+	}
+	last := len(w.Events)
+
+	tests := []struct {
+		seq    int
+		events int
+		missed bool
+	}{
+		{0, feedBacklog, true},
+		{last - feedBacklog - 1, feedBacklog, true},
+		{last - feedBacklog, feedBacklog, false},
+		{last - 5, 5, false},
+		{last, 0, false},
+	}
+	for _, tt := range tests {
+		events, state, missed := feedSince(w.ID, tt.seq)
+		if len(events) != tt.events || missed != tt.missed || len(state) == 0 {
+			t.Errorf("since %d: %d events, missed %t, %d state fields; want %d events, missed %t",
+				tt.seq, len(events), missed, len(state), tt.events, tt.missed)
+		}
+		if len(events) > 0 && events[len(events)-1].Seq != last {
+			t.Errorf("since %d: last event %d, want %d", tt.seq, events[len(events)-1].Seq, last)
+		}
+	}
+}
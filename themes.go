@@ -0,0 +1,62 @@
+package main
+
+// themeAdapter holds the nouns that change between themes so shared game
+// text (events, NPCs, objectives) reads right in a dungeon or a space station.
+type themeAdapter struct {
+	Merchant  string
+	Stranger  string
+	Shrine    string
+	Ambushers string
+	Relic     string
+	Path      string // what lies between rooms
+}
+
+var themeAdapters = map[string]themeAdapter{
+	"dungeon": {
+		Merchant:  "a hunched peddler with a creaking cart",
+		Stranger:  "a hooded figure leaning on a staff",
+		Shrine:    "a moss-eaten shrine to a forgotten god",
+		Ambushers: "goblin cutthroats",
+		Relic:     "ancient relic",
+		Path:      "corridor",
+	},
+	"city": {
+		Merchant:  "a street vendor hawking from a van",
+		Stranger:  "a stranger in a long coat",
+		Shrine:    "a graffiti-covered roadside memorial",
+		Ambushers: "street racers",
+		Relic:     "stolen trophy",
+		Path:      "back alley",
+	},
+	"space": {
+		Merchant:  "a drifting trader drone",
+		Stranger:  "a lone engineer in a scorched suit",
+		Shrine:    "a humming alien monolith",
+		Ambushers: "void pirates",
+		Relic:     "alien artifact",
+		Path:      "maintenance tube",
+	},
+	"cyberpunk": {
+		Merchant:  "a black-market fixer",
+		Stranger:  "a netrunner with mirrored eyes",
+		Shrine:    "a glitching data shrine",
+		Ambushers: "chrome-plated gangers",
+		Relic:     "encrypted data core",
+		Path:      "neon-lit passage",
+	},
+	"generic": {
+		Merchant:  "a wandering merchant",
+		Stranger:  "a mysterious stranger",
+		Shrine:    "a cursed shrine",
+		Ambushers: "bandits",
+		Relic:     "lost relic",
+		Path:      "passage",
+	},
+}
+
+func adapterFor(theme string) themeAdapter {
+	if a, ok := themeAdapters[theme]; ok {
+		return a
+	}
+	return themeAdapters["generic"]
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// A small server-side WebSocket (RFC 6455) on top of net/http: the handshake
+// hijacks the connection from a regular handler, after which wsConn reads
+// whole text messages and writes text and control frames. Extensions and
+// subprotocols are not negotiated.
+
+const (
+	wsGUID       = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	wsMaxMessage = 64 << 10
+	wsWriteWait  = 10 * time.Second
+
+	wsText   = 0x1
+	wsBinary = 0x2
+	wsClose  = 0x8
+	wsPing   = 0x9
+	wsPong   = 0xA
+)
+
+var errWSClosed = errors.New("websocket closed")
+
+type wsConn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	mu   sync.Mutex // serialises writes
+}
+
+func headerHas(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, t := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// upgradeWebSocket completes the opening handshake. On failure it has already
+// written an HTTP error. Browsers can open a WebSocket to any site without a
+// CORS preflight, so a page may only connect from this server's own origin or
+// one in cfg.AllowedOrigins; clients that send no Origin aren't browsers.
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != "GET" || !headerHas(r.Header, "Connection", "upgrade") ||
+		!headerHas(r.Header, "Upgrade", "websocket") || key == "" {
+		apierr.Write(w, r, apierr.New(apierr.UpgradeRequired, "websocket upgrade required"))
+		return nil, errors.New("not a websocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		apierr.Write(w, r, apierr.New(apierr.UpgradeRequired, "unsupported websocket version"))
+		return nil, errors.New("unsupported websocket version")
+	}
+	if origin := r.Header.Get("Origin"); origin != "" && !sameOrigin(r, origin) && !cfg.AllowsOrigin(origin) {
+		apierr.Write(w, r, apierr.New(apierr.Forbidden, "origin %s may not open a websocket", origin))
+		return nil, errors.New("origin not allowed")
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		apierr.Write(w, r, apierr.New(apierr.Internal, "websocket unsupported"))
+		return nil, errors.New("connection cannot be hijacked")
+	}
+	conn, rw, err := hj.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	// Drop the server's request timeouts; write sets its own deadline.
+	_ = conn.SetDeadline(time.Time{})
+	sum := sha1.Sum([]byte(key + wsGUID))
+	resp := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n"
+	if _, err := conn.Write([]byte(resp)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, br: rw.Reader}, nil
+}
+
+// sameOrigin reports whether origin names the host r was sent to.
+func sameOrigin(r *http.Request, origin string) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// readFrame reads one frame, unmasking its payload.
+func (c *wsConn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var h [2]byte
+	if _, err = io.ReadFull(c.br, h[:]); err != nil {
+		return
+	}
+	fin, op = h[0]&0x80 != 0, h[0]&0x0F
+	if h[1]&0x80 == 0 {
+		return fin, op, nil, errors.New("client frames must be masked")
+	}
+	n := uint64(h[1] & 0x7F)
+	switch n {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		n = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		n = binary.BigEndian.Uint64(ext[:])
+	}
+	if n > wsMaxMessage {
+		return fin, op, nil, errors.New("websocket frame too large")
+	}
+	var mask [4]byte
+	if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, n)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return fin, op, payload, nil
+}
+
+// ReadMessage returns the next text message, answering pings and closes on
+// the way. It returns errWSClosed once the peer has closed.
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	var msg []byte
+	started := false
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return nil, err
+		}
+		switch op {
+		case wsPing:
+			if err := c.write(wsPong, payload); err != nil {
+				return nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			_ = c.write(wsClose, payload)
+			return nil, errWSClosed
+		case wsBinary:
+			return nil, errors.New("binary messages are not supported")
+		case wsText:
+			if started {
+				return nil, errors.New("new message before the last one finished")
+			}
+			started = true
+		case 0x0: // continuation
+			if !started {
+				return nil, errors.New("continuation without a message")
+			}
+		default:
+			return nil, errors.New("unknown websocket opcode")
+		}
+		msg = append(msg, payload...)
+		if len(msg) > wsMaxMessage {
+			return nil, errors.New("websocket message too large")
+		}
+		if fin {
+			return msg, nil
+		}
+	}
+}
+
+func (c *wsConn) WriteText(data []byte) error { return c.write(wsText, data) }
+
+func (c *wsConn) write(op byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	frame := []byte{0x80 | op}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+	frame = append(frame, payload...)
+	_ = c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// Close sends a normal-closure frame and drops the connection.
+func (c *wsConn) Close() error {
+	_ = c.write(wsClose, []byte{0x03, 0xE8})
+	return c.conn.Close()
+}
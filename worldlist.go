@@ -0,0 +1,246 @@
+package main
+
+import (
+	"cmp"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// GET /worlds lists every world, in memory or saved under worlds/, as
+// summaries. Filters: theme, aesthetic, dimension, game_state (exact, any
+// case), q (substring of the prompt), created_after and created_before
+// (RFC 3339). sort is created (default), prompt, score or theme and order is
+// asc or desc (default desc for created and score, asc otherwise). Pages hold
+// limit worlds (default 20, at most 100); next_cursor, when present, fetches
+// the page after.
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+type WorldSummary struct {
+	ID         string    `json:"id"`
+	Prompt     string    `json:"prompt"`
+	Theme      string    `json:"theme"`
+	Aesthetic  string    `json:"aesthetic"`
+	Dimension  string    `json:"dimension"`
+	Difficulty string    `json:"difficulty"`
+	GameState  string    `json:"game_state"`
+	Player     string    `json:"player"`
+	Score      int       `json:"score"`
+	CreatedAt  time.Time `json:"created_at"`
+	Live       bool      `json:"live"` // loaded in memory and playable
+}
+
+type WorldPage struct {
+	Worlds     []WorldSummary `json:"worlds"`
+	Total      int            `json:"total"` // worlds matching the filters
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// listCursor marks the last world of a page, along with the ordering it was
+// taken from so it can't be replayed against another one.
+type listCursor struct {
+	Sort  string       `json:"s"`
+	Order string       `json:"o"`
+	Last  WorldSummary `json:"l"`
+}
+
+func summarize(w *World) WorldSummary {
+	return WorldSummary{
+		ID:         w.ID,
+		Prompt:     w.Prompt,
+		Theme:      w.Theme,
+		Aesthetic:  w.Aesthetic,
+		Dimension:  w.Dimension,
+		Difficulty: w.Difficulty,
+		GameState:  w.GameState,
+		Player:     w.Player,
+		Score:      w.Score,
+		CreatedAt:  w.CreatedAt,
+	}
+}
+
+// allWorlds summarizes the worlds in memory plus any saved ones that aren't
+// loaded. Older saves without created_at use their file's modification time.
+func allWorlds() []WorldSummary {
+	out := []WorldSummary{}
+	seen := map[string]bool{}
+	storeMu.Lock()
+	for id, w := range store {
+		worldMu.Lock()
+		s := summarize(w)
+		worldMu.Unlock()
+		s.Live = true
+		out = append(out, s)
+		seen[id] = true
+	}
+	storeMu.Unlock()
+
+	files, _ := filepath.Glob(dataPath("worlds", "world_*.json"))
+	for _, f := range files {
+		id := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(f), "world_"), ".json")
+		if seen[id] {
+			continue
+		}
+		data, err := os.ReadFile(f)
+		if err != nil {
+			continue
+		}
+		var w World
+		if err := json.Unmarshal(data, &w); err != nil {
+			continue
+		}
+		s := summarize(&w)
+		if s.ID == "" {
+			s.ID = id
+		}
+		if s.CreatedAt.IsZero() {
+			if info, err := os.Stat(f); err == nil {
+				s.CreatedAt = info.ModTime()
+			}
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// compareWorlds orders two summaries by field, breaking ties by ID so every
+// world has a fixed place for cursors to point at.
+func compareWorlds(a, b WorldSummary, field string) int {
+	var c int
+	switch field {
+	case "prompt":
+		c = strings.Compare(strings.ToLower(a.Prompt), strings.ToLower(b.Prompt))
+	case "score":
+		c = cmp.Compare(a.Score, b.Score)
+	case "theme":
+		c = strings.Compare(a.Theme, b.Theme)
+	default:
+		c = a.CreatedAt.Compare(b.CreatedAt)
+	}
+	if c == 0 {
+		c = strings.Compare(a.ID, b.ID)
+	}
+	return c
+}
+
+func listWorldsHandler(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	sortBy := q.Get("sort")
+	if sortBy == "" {
+		sortBy = "created"
+	}
+	switch sortBy {
+	case "created", "prompt", "score", "theme":
+	default:
+		apierr.Write(w, r, apierr.Invalid("sort", "sort must be one of created, prompt, score, theme"))
+		return
+	}
+	order := q.Get("order")
+	if order == "" {
+		order = "asc"
+		if sortBy == "created" || sortBy == "score" {
+			order = "desc"
+		}
+	}
+	if order != "asc" && order != "desc" {
+		apierr.Write(w, r, apierr.Invalid("order", "order must be asc or desc"))
+		return
+	}
+	limit := defaultListLimit
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			apierr.Write(w, r, apierr.Invalid("limit", "limit must be a positive number"))
+			return
+		}
+		limit = min(n, maxListLimit)
+	}
+	var after, before time.Time
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"created_after", &after}, {"created_before", &before}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				apierr.Write(w, r, apierr.Invalid(p.name, "%s must be an RFC 3339 time", p.name))
+				return
+			}
+			*p.dst = t
+		}
+	}
+	var cur *listCursor
+	if v := q.Get("cursor"); v != "" {
+		cur = &listCursor{}
+		data, err := base64.RawURLEncoding.DecodeString(v)
+		if err == nil {
+			err = json.Unmarshal(data, cur)
+		}
+		if err != nil {
+			apierr.Write(w, r, apierr.Invalid("cursor", "invalid cursor"))
+			return
+		}
+		if cur.Sort != sortBy || cur.Order != order {
+			apierr.Write(w, r, apierr.Invalid("cursor", "cursor was issued for a different sort order"))
+			return
+		}
+	}
+
+	text := strings.ToLower(q.Get("q"))
+	matches := []WorldSummary{}
+	for _, s := range allWorlds() {
+		if !matchField(s.Theme, q.Get("theme")) || !matchField(s.Aesthetic, q.Get("aesthetic")) ||
+			!matchField(s.Dimension, q.Get("dimension")) || !matchField(s.GameState, q.Get("game_state")) {
+			continue
+		}
+		if text != "" && !strings.Contains(strings.ToLower(s.Prompt), text) {
+			continue
+		}
+		if !after.IsZero() && !s.CreatedAt.After(after) || !before.IsZero() && !s.CreatedAt.Before(before) {
+			continue
+		}
+		matches = append(matches, s)
+	}
+	dir := 1
+	if order == "desc" {
+		dir = -1
+	}
+	slices.SortFunc(matches, func(a, b WorldSummary) int {
+		return dir * compareWorlds(a, b, sortBy)
+	})
+
+	page := WorldPage{Total: len(matches), Worlds: []WorldSummary{}}
+	start := 0
+	if cur != nil {
+		start = len(matches)
+		for i, s := range matches {
+			if dir*compareWorlds(s, cur.Last, sortBy) > 0 {
+				start = i
+				break
+			}
+		}
+	}
+	end := min(start+limit, len(matches))
+	page.Worlds = append(page.Worlds, matches[start:end]...)
+	if end < len(matches) {
+		data, _ := json.Marshal(listCursor{Sort: sortBy, Order: order, Last: matches[end-1]})
+		page.NextCursor = base64.RawURLEncoding.EncodeToString(data)
+	}
+	writeJSON(w, page)
+}
+
+func matchField(value, want string) bool {
+	return want == "" || strings.EqualFold(value, want)
+}
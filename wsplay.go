@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/NarlaRohan050/Voidspark/internal/apierr"
+)
+
+// GET /ws upgrades to a WebSocket so a client can play a session over one
+// connection. Every message is a JSON object with a "type":
+//
+//	client → server
+//	  {"type": "subscribe", "id": "<world id>", "last_seq": 0}
+//	      follow a world; the server answers with every event after last_seq
+//	      still in the feed's backlog and then its state, and keeps both
+//	      coming as the world is played
+//	  {"type": "act", "action": "explore", ...}
+//	      play on the subscribed world. action is party (roles), explore,
+//	      flee (direction), choose (choice), revive (hero), talk (npc,
+//	      option) or equip (hero, item or slot); the reply is the new state
+//	server → client
+//	  {"type": "state", "state": {...}}  the player view, minus events and log
+//	  {"type": "event", "event": {...}}  one LogEvent
+//	  {"type": "error", "error": {...}, "status": 409}  the error envelope body
+//
+// Events and state come from the same feed as the SSE stream, so a world can
+// be followed from both at once.
+
+const wsPingEvery = 30 * time.Second
+
+// wsSessions counts running WebSocket handlers. Shutdown forgets about a
+// connection once it is hijacked, so main waits on this before the final
+// flushWorlds.
+var wsSessions sync.WaitGroup
+
+type wsRequest struct {
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	LastSeq int    `json:"last_seq"`
+	Action
+}
+
+type wsReply struct {
+	Type   string                     `json:"type"`
+	State  map[string]json.RawMessage `json:"state,omitempty"`
+	Event  *LogEvent                  `json:"event,omitempty"`
+	Error  *apierr.Error              `json:"error,omitempty"`
+	Status int                        `json:"status,omitempty"`
+}
+
+// wsSession is one connection. Only the goroutine running wsHandler writes
+// through it.
+type wsSession struct {
+	conn      *wsConn
+	requestID string // of the upgrade request
+	world     *World
+	wake      chan struct{}
+	seq       int
+	sent      map[string]json.RawMessage
+}
+
+func wsHandler(w http.ResponseWriter, r *http.Request) {
+	// Added before the hijack, while Shutdown still waits for this request.
+	wsSessions.Add(1)
+	defer wsSessions.Done()
+	conn, err := upgradeWebSocket(w, r)
+	if err != nil {
+		return
+	}
+	s := &wsSession{conn: conn, requestID: apierr.RequestID(r.Context())}
+	defer conn.Close()
+	defer s.unsubscribe()
+
+	incoming := make(chan []byte)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(incoming)
+		for {
+			msg, err := conn.ReadMessage()
+			if err != nil {
+				if err != errWSClosed && err != io.EOF && !errors.Is(err, net.ErrClosed) {
+					log.Printf("websocket read: %v", err)
+				}
+				return
+			}
+			select {
+			case incoming <- msg:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(wsPingEvery)
+	defer ping.Stop()
+	for {
+		var err error
+		select {
+		case msg, ok := <-incoming:
+			if !ok {
+				return
+			}
+			err = s.handle(msg)
+		case _, ok := <-s.wake:
+			if !ok {
+				// The world was deleted.
+				s.world, s.wake = nil, nil
+				err = s.fail(apierr.New(apierr.WorldDeleted, "world deleted"))
+				break
+			}
+			err = s.flush(false)
+		case <-ping.C:
+			err = conn.write(wsPing, nil)
+		case <-closing:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// waitSessions waits up to d for the WebSocket sessions to end and reports
+// whether they all did.
+func waitSessions(d time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wsSessions.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
+// handle answers one client message. Only write failures are returned; game
+// errors go back to the client as error messages.
+func (s *wsSession) handle(msg []byte) error {
+	var req wsRequest
+	if err := json.Unmarshal(msg, &req); err != nil {
+		return s.fail(apierr.BadBody(err))
+	}
+	switch req.Type {
+	case "subscribe":
+		storeMu.Lock()
+		wld, ok := store[req.ID]
+		storeMu.Unlock()
+		if !ok {
+			return s.fail(apierr.New(apierr.NotFound, "session not found"))
+		}
+		s.unsubscribe()
+		s.world, s.wake, s.seq, s.sent = wld, subscribe(wld), req.LastSeq, nil
+		return s.flush(true)
+	case "act":
+		if s.world == nil {
+			return s.fail(apierr.New(apierr.NotSubscribed, "subscribe to a world first"))
+		}
+		if err := perform(s.world, req.Action); err != nil {
+			return s.fail(err)
+		}
+		return s.flush(true)
+	default:
+		return s.fail(apierr.Invalid("type", "unknown message type: %q", req.Type))
+	}
+}
+
+// flush sends the events the client hasn't seen, then the state if it
+// changed (or always, when force is set or events were missed).
+func (s *wsSession) flush(force bool) error {
+	events, state, missed := feedSince(s.world.ID, s.seq)
+	force = force || missed
+	for i := range events {
+		if err := s.send(wsReply{Type: "event", Event: &events[i]}); err != nil {
+			return err
+		}
+		s.seq = events[i].Seq
+	}
+	if !force && len(stateDiff(s.sent, state)) == 0 {
+		return nil
+	}
+	s.sent = state
+	return s.send(wsReply{Type: "state", State: state})
+}
+
+// fail reports err to the client, game rule errors as invalid_action.
+func (s *wsSession) fail(err error) error {
+	reply := *asAPIError(err)
+	reply.RequestID = s.requestID
+	return s.send(wsReply{Type: "error", Error: &reply, Status: reply.Status()})
+}
+
+func (s *wsSession) send(reply wsReply) error {
+	data, err := json.Marshal(reply)
+	if err != nil {
+		return err
+	}
+	return s.conn.WriteText(data)
+}
+
+func (s *wsSession) unsubscribe() {
+	if s.world != nil {
+		unsubscribe(s.world.ID, s.wake)
+		s.world, s.wake = nil, nil
+	}
+}